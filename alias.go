@@ -0,0 +1,60 @@
+package configstore
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	aliases    = map[string]string{}
+	aliasesMut sync.Mutex
+	warned     = map[string]bool{}
+)
+
+// Alias declares that items stored under the deprecated key should also be found under key.
+// This eases configuration key renaming: both keys are available during the transition, and a
+// deprecation warning is logged once for each provider still using the deprecated key.
+func Alias(key, deprecated string) {
+	aliasesMut.Lock()
+	defer aliasesMut.Unlock()
+	if key == deprecated {
+		return
+	}
+	k, ok := aliases[deprecated]
+	if ok && k != key {
+		panic(fmt.Sprintf("conflict on configuration alias: %s (%s / %s)", deprecated, k, key))
+	}
+	aliases[deprecated] = key
+}
+
+// Appends a copy of the items using a deprecated key, under the new key.
+// providerName is only used to identify the source in the deprecation warning.
+func resolveAliases(providerName string, items []Item) []Item {
+	aliasesMut.Lock()
+	defer aliasesMut.Unlock()
+	if len(aliases) == 0 {
+		return items
+	}
+	ret := items
+	for _, it := range items {
+		key, ok := aliases[it.key]
+		if !ok {
+			continue
+		}
+		w := fmt.Sprintf("%s:%s", providerName, it.key)
+		if !warned[w] {
+			warned[w] = true
+			logrus.Warnf("configstore: provider '%s': key '%s' is deprecated, use '%s' instead", providerName, it.key, key)
+		}
+		aliased := it
+		aliased.key = key
+		if len(ret) == len(items) {
+			// do not modify the provider's slice
+			ret = append([]Item{}, items...)
+		}
+		ret = append(ret, aliased)
+	}
+	return ret
+}
@@ -3,6 +3,7 @@ package configstore
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"sync"
@@ -15,8 +16,12 @@ const (
 
 var (
 	providers             = map[string]Provider{}
+	providerSeq           = map[string]int{}
+	providerCount         int
 	pMut                  sync.Mutex
 	allowProviderOverride bool
+	allowPartialFailure   bool
+	optionalProviders     = map[string]bool{}
 
 	providerFactories = map[string]func(string){}
 	pFactMut          sync.Mutex
@@ -26,6 +31,11 @@ func init() {
 	RegisterProviderFactory("file", File)
 	RegisterProviderFactory("filelist", FileList)
 	RegisterProviderFactory("filetree", FileTree)
+	RegisterProviderFactory("http", HTTP)
+	RegisterProviderFactory("consul", Consul)
+	RegisterProviderFactory("vault", Vault)
+	RegisterProviderFactory("k8smount", K8sMount)
+	RegisterProviderFactory("git", Git)
 }
 
 // A Provider retrieves config items and makes them available to the configstore,
@@ -34,20 +44,34 @@ func init() {
 // It's the responsability of the application using configstore to register suitable providers.
 type Provider func() (ItemList, error)
 
-// RegisterProvider registers a provider
+// RegisterProvider registers a provider.
+// It is registered in LayerDefaults, or in the layer assigned by InitFromEnvironment. See RegisterProviderLayer.
 func RegisterProvider(name string, f Provider) {
 	pMut.Lock()
-	defer pMut.Unlock()
+	replaced := registerProvider(name, defaultLayer, f)
+	pMut.Unlock()
+	closeReplaced(name, replaced)
+}
+
+// Registers a provider, returns the closer of the provider it overrides, if any (see AllowProviderOverride).
+// NOT CONCURRENT SAFE, pMut must be held.
+func registerProvider(name string, layer Layer, f Provider) io.Closer {
 	_, ok := providers[name]
 	if ok && !allowProviderOverride {
 		panic(fmt.Sprintf("conflict on configuration provider: %s", name))
 	}
+	replaced := closers[name]
+	delete(closers, name)
 	providers[name] = f
+	providerLayers[name] = layer
+	providerCount++
+	providerSeq[name] = providerCount
+	return replaced
 }
 
 // AllowProviderOverride allows multiple calls to RegisterProvider() with the same provider name.
 // This is useful for controlled test cases, but is not recommended in the context of a real
-// application.
+// application. The overridden provider gets closed (see RegisterProviderCloser).
 func AllowProviderOverride() {
 	fmt.Fprintln(os.Stderr, "configstore: ATTENTION: PROVIDER OVERRIDE ALLOWED/ENABLED")
 	pMut.Lock()
@@ -55,6 +79,21 @@ func AllowProviderOverride() {
 	allowProviderOverride = true
 }
 
+// SetProviderOptional flags a registered provider as optional: when it fails, GetItemList still returns the items
+// of the other providers, and the error is available via ItemList.Errors.
+func SetProviderOptional(name string) {
+	pMut.Lock()
+	defer pMut.Unlock()
+	optionalProviders[name] = true
+}
+
+// AllowPartialFailure flags all providers as optional, see SetProviderOptional.
+func AllowPartialFailure() {
+	pMut.Lock()
+	defer pMut.Unlock()
+	allowPartialFailure = true
+}
+
 // RegisterProviderFactory registers a factory function so that InitFromEnvironment can properly
 // instantiate configuration providers via name + argument.
 func RegisterProviderFactory(name string, f func(string)) {
@@ -70,9 +109,11 @@ func RegisterProviderFactory(name string, f func(string)) {
 // InitFromEnvironment initializes configuration providers via their name and an optional argument.
 // Suitable provider factories should have been registered via RegisterProviderFactory for this to work.
 // Built-in providers (File, FileList, FileTree, ...) are registered by default.
+// The providers get registered in increasing layers, starting from LayerFiles, in the order of the list:
+// later entries override earlier ones (see RegisterProviderLayer).
 //
 // Valid example:
-// CONFIGURATION_FROM=file:/etc/myfile.conf,file:/etc/myfile2.conf,filelist:/home/foobar/configs
+// CONFIGURATION_FROM=file:/etc/myfile.conf,file:/etc/myfile2.conf,filelist:/home/foobar/configs,http:https://cfg.internal/app.json
 func InitFromEnvironment() {
 
 	pFactMut.Lock()
@@ -83,7 +124,10 @@ func InitFromEnvironment() {
 		return
 	}
 	cfgList := strings.Split(cfg, ",")
-	for _, c := range cfgList {
+	prevLayer := setDefaultLayer(LayerFiles)
+	defer setDefaultLayer(prevLayer)
+	for i, c := range cfgList {
+		setDefaultLayer(LayerFiles + Layer(i))
 		parts := strings.SplitN(c, ":", 2)
 		name := c
 		arg := ""
@@ -109,6 +153,7 @@ var (
 
 // Watch returns a channel which you can range over.
 // You will get unblocked every time a provider notifies of a configuration change.
+// The channel gets closed by Shutdown.
 func Watch() chan struct{} {
 	// buffer size == 1, notifications will never use a blocking write
 	newCh := make(chan struct{}, 1)
@@ -118,6 +163,19 @@ func Watch() chan struct{} {
 	return newCh
 }
 
+// Unwatch stops the notifications on a channel returned by Watch, and closes it.
+func Unwatch(ch chan struct{}) {
+	watchersMut.Lock()
+	defer watchersMut.Unlock()
+	for i, w := range watchers {
+		if w == ch {
+			watchers = append(watchers[:i], watchers[i+1:]...)
+			close(ch)
+			return
+		}
+	}
+}
+
 // NotifyWatchers is used by providers to notify of configuration changes.
 // It unblocks all the watchers which are ranging over a watch channel.
 func NotifyWatchers() {
@@ -1,8 +1,12 @@
 package configstore
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"os"
-	"reflect"
+	"path/filepath"
+	"regexp"
 	"testing"
 	"time"
 
@@ -139,26 +143,571 @@ func TestStore(t *testing.T) {
 
 	// Check item not found
 	_, err = items.GetItem("notfound")
-	assert.Equal(mustType(err, ErrItemNotFound("")), true)
+	assert.Equal(errors.Is(err, ErrNotFound), true)
 
 	_, err = items.GetItem("duration")
-	assert.Equal(mustType(err, ErrItemNotFound("")), false)
+	assert.Equal(errors.Is(err, ErrNotFound), false)
 
 	// Check uninitialized item list
 	tmp, items := items, nil
 	_, err = items.GetItem("duration")
-	assert.Equal(mustType(err, ErrUninitializedItemList("")), true)
+	assert.Equal(errors.Is(err, ErrUninitialized), true)
 	items = tmp
 
 	_, err = items.GetItem("duration")
-	assert.Equal(mustType(err, ErrUninitializedItemList("")), false)
+	assert.Equal(errors.Is(err, ErrUninitialized), false)
 
 	// Check ambigous item
 	_, err = items.GetItem("sql")
-	assert.Equal(mustType(err, ErrAmbiguousItem("")), true)
+	assert.Equal(errors.Is(err, ErrAmbiguous), true)
 
 	_, err = items.GetItem("duration")
-	assert.Equal(mustType(err, ErrAmbiguousItem("")), false)
+	assert.Equal(errors.Is(err, ErrAmbiguous), false)
+}
+
+type ValidatedDBItem struct {
+	ID   string `json:"id" validate:"required"`
+	Type string `json:"type" validate:"oneof=RO RW"`
+	Port int    `json:"port" validate:"min=1,max=65535"`
+}
+
+func (d *ValidatedDBItem) Validate() error {
+	if d.ID == "47" {
+		return errors.New("deprecated db")
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	items := &ItemList{
+		Items: []Item{
+			NewItem("ok", `{"id":"42","type":"RO","port":5432}`, 0),
+			NewItem("missing", `{"type":"RO","port":5432}`, 0),
+			NewItem("oneof", `{"id":"42","type":"XX","port":5432}`, 0),
+			NewItem("max", `{"id":"42","type":"RW","port":70000}`, 0),
+			NewItem("custom", `{"id":"47","type":"RW","port":5432}`, 0),
+		},
+	}
+	items = Filter().Unmarshal(func() interface{} { return &ValidatedDBItem{} }).Apply(items)
+
+	i, _ := items.GetItem("ok")
+	_, err := i.Unmarshaled()
+	assert.NoError(err)
+
+	for _, k := range []string{"missing", "oneof", "max"} {
+		i, _ = items.GetItem(k)
+		_, err = i.Unmarshaled()
+		assert.Equal(errors.Is(err, ErrInvalid), true, k)
+	}
+
+	i, _ = items.GetItem("custom")
+	_, err = i.Unmarshaled()
+	assert.EqualError(err, "configstore: validate 'custom': deprecated db")
+	assert.Equal(errors.Is(err, ErrInvalid), true)
+}
+
+func TestUsage(t *testing.T) {
+	assert := assert.New(t)
+
+	f := RegisterFilter("db", Filter().Slice("database").Unmarshal(func() interface{} { return &DBItem{} }), "main database")
+	RegisterFilter("loglevel", Filter().Slice("log-level").Default("info"), "log level | verbosity")
+
+	v, err := f.Default(`{"id":"1"}`).Slice("database").GetItemValue("database")
+	assert.NoError(err)
+	assert.Equal(`{"id":"1"}`, v)
+
+	routing := RegisterFilter("routing", Filter().SliceRegexp(regexp.MustCompile(`^(db|cache)\.`)).Default("x"), "db or cache")
+	// no key to give to a fallback item
+	assert.Len(routing.Apply(&ItemList{}).Items, 0)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(Usage(buf))
+	assert.Contains(buf.String(), "database")
+	assert.Contains(buf.String(), `{"id":"","type":""}`)
+	assert.Contains(buf.String(), "info")
+
+	buf.Reset()
+	assert.NoError(UsageMarkdown(buf))
+	assert.Equal("| Name | Key | Type | Default | Description |\n|---|---|---|---|---|\n"+
+		"| db | `database` | `{\"id\":\"\",\"type\":\"\"}` |  | main database |\n"+
+		"| loglevel | `log-level` | `string` | `info` | log level \\| verbosity |\n"+
+		"| routing | `^(db\\|cache)\\.` | `string` | `x` | db or cache |\n", buf.String())
+}
+
+func TestAlias(t *testing.T) {
+	assert := assert.New(t)
+
+	Alias("db.url", "database-url")
+	InMemory("alias-test").Add(NewItem("database-url", "postgres://db", 0))
+
+	v, err := GetItemValue("db.url")
+	assert.NoError(err)
+	assert.Equal("postgres://db", v)
+
+	v, err = GetItemValue("database-url")
+	assert.NoError(err)
+	assert.Equal("postgres://db", v)
+
+	i, err := GetItem("db.url")
+	assert.NoError(err)
+	assert.Equal("alias-test", i.Provider())
+}
+
+func TestHierarchicalKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	items := (&ItemList{
+		Items: []Item{
+			NewItem("database.host", "localhost", 0),
+			NewItem("database.port", "5432", 0),
+			NewItem("db-main", "main", 0),
+			NewItem("db-replica", "replica", 0),
+			NewItem("db/main/url", "postgres://main", 0),
+			NewItem("log-level", "info", 0),
+		},
+	}).index()
+
+	assert.ElementsMatch(Filter().SlicePrefix("database.").Apply(items).Keys(), []string{"database.host", "database.port"})
+	assert.ElementsMatch(Filter().SubTree("database.").Apply(items).Keys(), []string{"host", "port"})
+	assert.ElementsMatch(Filter().SliceGlob("db-*").Apply(items).Keys(), []string{"db-main", "db-replica"})
+	assert.ElementsMatch(Filter().SliceGlob("db/*").Apply(items).Keys(), []string{})
+	assert.ElementsMatch(Filter().SliceGlob("db/*/*").Apply(items).Keys(), []string{"db/main/url"})
+	assert.ElementsMatch(Filter().SliceGlob("data*.p*").Apply(items).Keys(), []string{"database.port"})
+	assert.ElementsMatch(Filter().SliceGlob("d*").Apply(items).Keys(), []string{"database.host", "database.port", "db-main", "db-replica"})
+	assert.ElementsMatch(Filter().SliceRegexp(regexp.MustCompile(`^db[-/]main`)).Apply(items).Keys(), []string{"db-main", "db/main/url"})
+
+	v, err := Filter().SubTree("database.").Apply(items).GetItemValueInt("port")
+	assert.NoError(err)
+	assert.Equal(int64(5432), v)
+
+	assert.Equal("database.*: string", Filter().SlicePrefix("database.").String())
+}
+
+func TestMerge(t *testing.T) {
+	assert := assert.New(t)
+
+	items := (&ItemList{
+		Items: []Item{
+			NewItem("database", "host: localhost\nport: 5432\noptions:\n  ssl: true\ntags: [a]", 1),
+			NewItem("database", `{"port":5433,"tags":["b"]}`, 2),
+			NewItem("other", `low`, 1),
+			NewItem("other", `high`, 2),
+		},
+	}).index()
+
+	merged := Filter().Merge().Apply(items)
+	assert.Len(merged.Items, 2)
+	v, err := merged.GetItemValue("database")
+	assert.NoError(err)
+	assert.JSONEq(`{"host":"localhost","port":5433,"options":{"ssl":true},"tags":["b"]}`, v)
+	v, err = merged.GetItemValue("other")
+	assert.NoError(err)
+	assert.Equal("high", v)
+
+	v, err = Filter().MergeWith(ListAppend).Apply(items).GetItemValue("database")
+	assert.NoError(err)
+	assert.JSONEq(`{"host":"localhost","port":5433,"options":{"ssl":true},"tags":["a","b"]}`, v)
+
+	items = (&ItemList{
+		Items: []Item{
+			NewItem("database", `{"host":"localhost","user":"a"}`, 1),
+			NewItem("database", `{"user":"b"}`, 1),
+			NewItem("database", "{broken", 1),
+			NewItem("database", `{"port":5433}`, 2),
+		},
+	}).index()
+	for i := 0; i < 10; i++ {
+		v, err = Filter().Merge().Apply(items).GetItemValue("database")
+		assert.NoError(err)
+		assert.JSONEq(`{"host":"localhost","port":5433,"user":"b"}`, v)
+	}
+
+	app, defaults := NewItem("database", `{"user":"a"}`, 1), NewItem("database", `{"user":"b"}`, 1)
+	app.provider, defaults.provider = "file:20-app.yml", "file:10-defaults.yml"
+	items = (&ItemList{Items: []Item{app, defaults}}).index()
+	v, err = Filter().MergeWithTieBreak(ListReplace, TieBreakLexical).Apply(items).GetItemValue("database")
+	assert.NoError(err)
+	assert.JSONEq(`{"user":"a"}`, v)
+}
+
+func TestFlatten(t *testing.T) {
+	assert := assert.New(t)
+
+	items := (&ItemList{
+		Items: []Item{
+			NewItem("database", "host: localhost\nport: 5432\noptions:\n  ssl: true\ntags: [a, b]", 3),
+			NewItem("other", `plain`, 1),
+		},
+	}).index()
+
+	flat := Filter().Flatten(".").Apply(items)
+	assert.ElementsMatch(flat.Keys(), []string{"database.host", "database.port", "database.options.ssl", "database.tags", "other"})
+	i, err := flat.GetItem("database.options.ssl")
+	assert.NoError(err)
+	assert.Equal(int64(3), i.Priority())
+	assert.Equal(true, must(i.ValueBool()))
+	assert.Equal(int64(5432), must(flat.GetItemValueInt("database.port")))
+	assert.Equal(`["a","b"]`, must(flat.GetItemValue("database.tags")))
+
+	nested := Filter().Flatten(".").Nest(".").Apply(items)
+	assert.ElementsMatch(nested.Keys(), []string{"database", "other"})
+	v, err := nested.GetItemValue("database")
+	assert.NoError(err)
+	assert.JSONEq(`{"host":"localhost","port":5432,"options":{"ssl":true},"tags":["a","b"]}`, v)
+	assert.Equal("plain", must(nested.GetItemValue("other")))
+
+	leaves := (&ItemList{
+		Items: []Item{
+			NewItem("flags.enabled", "off", 1),
+			NewItem("flags.answer", "no", 1),
+			NewItem("flags.code", "01234", 1),
+			NewItem("flags.count", "3", 1),
+		},
+	}).index()
+	v, err = Filter().Nest(".").Apply(leaves).GetItemValue("flags")
+	assert.NoError(err)
+	assert.JSONEq(`{"enabled":"off","answer":"no","code":"01234","count":3}`, v)
+
+	for i := 0; i < 20; i++ {
+		collision := (&ItemList{
+			Items: []Item{
+				NewItem("a.b", "1", 1),
+				NewItem("a.b.c", "2", 1),
+				NewItem("x.y", "3", 1),
+			},
+		}).index()
+		nested = Filter().Nest(".").Apply(collision)
+		_, err = nested.GetItemValue("a")
+		assert.Equal(errors.Is(err, ErrInvalid), true)
+		assert.Equal(`{"y":3}`, must(nested.GetItemValue("x")))
+	}
+}
+
+func TestSquashTieBreak(t *testing.T) {
+	assert := assert.New(t)
+
+	InMemory("tiebreak-b").Add(NewItem("tiebreak", "b", 1), NewItem("tiebreak-same", "x", 1))
+	InMemory("tiebreak-a").Add(NewItem("tiebreak", "a", 1), NewItem("tiebreak-same", "x", 1))
+
+	items, err := Filter().SlicePrefix("tiebreak").GetItemList()
+	assert.NoError(err)
+
+	conflicts := items.Conflicts()
+	assert.Len(conflicts, 1)
+	assert.Equal("tiebreak", conflicts[0].Key)
+	assert.Len(conflicts[0].Items, 2)
+
+	_, err = Filter().Squash().Apply(items).GetItem("tiebreak")
+	assert.Equal(errors.Is(err, ErrAmbiguous), true)
+
+	assert.Equal("a", must(Filter().SquashWith(TieBreakRegistrationOrder).Apply(items).GetItemValue("tiebreak")))
+	assert.Equal("b", must(Filter().SquashWith(TieBreakLexical).Apply(items).GetItemValue("tiebreak")))
+	assert.Equal("b", must(Filter().SquashWith(TieBreakProviderRank("tiebreak-b", "tiebreak-a")).Apply(items).GetItemValue("tiebreak")))
+	assert.Equal("x", must(Filter().SquashWith(TieBreakLexical).Apply(items).GetItemValue("tiebreak-same")))
+}
+
+func TestLayers(t *testing.T) {
+	assert := assert.New(t)
+
+	RegisterProviderFactory("layertest", func(s string) {
+		InMemory("layertest:" + s).Add(NewItem("layered", s, int64(len(s))))
+	})
+	os.Setenv(ConfigEnvVar, "layertest:longer-value, layertest:short")
+	InitFromEnvironment()
+	RegisterProviderLayer("layertest:flags", LayerFlags, func() (ItemList, error) {
+		return ItemList{Items: []Item{NewItem("layered-flag", "flag", -10)}}, nil
+	})
+	InMemory("layertest:defaults").Add(NewItem("layered", "default", 100), NewItem("layered-flag", "default", 100))
+
+	items, err := Filter().SlicePrefix("layered").Squash().GetItemList()
+	assert.NoError(err)
+
+	i, err := items.GetItem("layered")
+	assert.NoError(err)
+	assert.Equal("layertest:short", i.Provider())
+	assert.Equal(LayerFiles+1, i.Layer())
+
+	assert.Equal("flag", must(items.GetItemValue("layered-flag")))
+}
+
+func TestErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	cause := errors.New("connection refused")
+	ErrorProvider("errors-test", cause)
+	_, err := GetItemList()
+	assert.True(errors.Is(err, ErrProviderFail))
+	assert.True(errors.Is(err, cause))
+	var perr ErrProvider
+	assert.True(errors.As(err, &perr))
+	assert.Equal("errors-test", perr.Provider)
+	assert.EqualError(err, "configstore: provider 'errors-test': connection refused")
+	assert.NoError(UnregisterProvider("errors-test"))
+
+	_, err = (&ItemList{}).GetItem("missing")
+	var nerr ErrItemNotFound
+	assert.True(errors.As(err, &nerr))
+	assert.Equal("missing", nerr.Key)
+	assert.False(errors.Is(err, ErrAmbiguous))
+	// type assertions written against the former string types still work
+	_, ok := err.(ErrItemNotFound)
+	assert.True(ok)
+	_, ok = err.(ErrAmbiguousItem)
+	assert.False(ok)
+}
+
+func TestPartialFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	cause := errors.New("timeout")
+	ErrorProvider("partial-test", cause)
+	SetProviderOptional("partial-test")
+	InMemory("partial-test-ok").Add(NewItem("partial", "value", 0))
+
+	items, err := Filter().Slice("partial").GetItemList()
+	assert.NoError(err)
+	assert.Equal("value", must(items.GetItemValue("partial")))
+	assert.True(errors.Is(items.Errors(), cause))
+	assert.Len(items.Errors(), 1)
+	assert.NoError(UnregisterProvider("partial-test"))
+
+	items, err = GetItemList()
+	assert.NoError(err)
+	assert.NoError(items.Errors())
+}
+
+func TestLastKnownGood(t *testing.T) {
+	assert := assert.New(t)
+
+	cache := filepath.Join(t.TempDir(), "cache.json")
+	fail := false
+	p := func() (ItemList, error) {
+		if fail {
+			return ItemList{}, errors.New("unavailable")
+		}
+		secret := NewSensitiveItem("lkg.secret", "hunter2", 0)
+		secret.revision = "5265e6"
+		return ItemList{Items: []Item{NewItem("lkg", "value", 3), secret}}, nil
+	}
+
+	lkg := WithLastKnownGood(p).Persist(cache)
+	l, err := lkg.Items()
+	assert.NoError(err)
+	assert.False(lkg.Stale())
+	assert.False(l.Items[0].Stale())
+
+	// unchanged items are not written again
+	written, err := os.Stat(cache)
+	assert.NoError(err)
+	assert.NoError(os.Chtimes(cache, time.Time{}, written.ModTime().Add(-time.Hour)))
+	_, err = lkg.Items()
+	assert.NoError(err)
+	finfo, err := os.Stat(cache)
+	assert.NoError(err)
+	assert.Equal(written.ModTime().Add(-time.Hour), finfo.ModTime())
+
+	fail = true
+	l, err = lkg.Items()
+	assert.NoError(err)
+	assert.True(lkg.Stale())
+	assert.EqualError(lkg.Err(), "unavailable")
+	assert.Len(l.Items, 2)
+	assert.True(l.Items[0].Stale())
+	assert.Equal("value", mustValue(l.Items[0]))
+
+	// restart during the outage
+	restarted := WithLastKnownGood(p).Persist(cache)
+	l, err = restarted.Items()
+	assert.NoError(err)
+	assert.True(restarted.Stale())
+	assert.Equal(int64(3), l.Items[0].Priority())
+	// the sensitive flag survives the restart
+	assert.False(l.Items[0].Sensitive())
+	assert.True(l.Items[1].Sensitive())
+	assert.Equal("5265e6", l.Items[1].Revision())
+	assert.True(restarted.Age() > 0)
+
+	_, err = WithLastKnownGood(p).Items()
+	assert.EqualError(err, "unavailable")
+}
+
+func TestRetry(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := 0
+	p := func() (ItemList, error) {
+		calls++
+		if calls < 3 {
+			return ItemList{}, errors.New("flaky")
+		}
+		return ItemList{Items: []Item{NewItem("retry", "value", 0)}}, nil
+	}
+
+	attempts := []int{}
+	policy := RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		Jitter:         0.5,
+		OnAttempt:      func(attempt int, err error) { attempts = append(attempts, attempt) },
+	}
+	l, err := WithRetry(p, policy)()
+	assert.NoError(err)
+	assert.Len(l.Items, 1)
+	assert.Equal([]int{1, 2}, attempts)
+
+	calls, attempts = -10, nil
+	_, err = WithRetry(p, policy)()
+	assert.EqualError(err, "flaky")
+	assert.Equal([]int{1, 2, 3}, attempts)
+
+	// the context of the call bounds the retries
+	fetch := func(ctx context.Context) ([]Item, error) {
+		l, err := p()
+		return l.Items, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	calls, attempts = -10, nil
+	slow := policy
+	slow.InitialBackoff = time.Hour
+	_, err = WithRetryFetch(fetch, slow)(ctx)
+	assert.EqualError(err, "flaky")
+	assert.Equal([]int{1}, attempts)
+
+	calls, attempts = 0, nil
+	items, err := WithRetryFetch(fetch, policy)(context.Background())
+	assert.NoError(err)
+	assert.Len(items, 1)
+	assert.Equal([]int{1, 2}, attempts)
+
+	// the budget applies to each call
+	policy.MaxAttempts = 10
+	policy.InitialBackoff = 20 * time.Millisecond
+	policy.Jitter = 0
+	policy.MaxElapsed = 50 * time.Millisecond
+	retry := WithRetry(p, policy)
+	for i := 0; i < 2; i++ {
+		calls, attempts = -10, nil
+		_, err = retry()
+		assert.EqualError(err, "flaky")
+		assert.Equal([]int{1, 2}, attempts)
+	}
+}
+
+func TestPolling(t *testing.T) {
+	assert := assert.New(t)
+
+	values := make(chan string, 10)
+	values <- "v1"
+	current := ""
+	fetch := func(ctx context.Context) ([]Item, error) {
+		select {
+		case current = <-values:
+		default:
+		}
+		return []Item{NewItem("polling", current, 0)}, nil
+	}
+
+	w := Watch()
+	p := Polling("polling-test", time.Millisecond, fetch)
+	assert.Equal("v1", must(GetItemValue("polling")))
+
+	values <- "v2"
+	select {
+	case <-w:
+	case <-time.After(time.Second):
+		t.Fatal("watchers were not notified")
+	}
+	assert.Equal("v2", must(GetItemValue("polling")))
+
+	// no change, no notification
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-w:
+		t.Error("unexpected notification")
+	default:
+	}
+
+	assert.NoError(UnregisterProvider("polling-test"))
+	<-p.Done()
+
+	// a non-positive interval falls back to the default instead of panicking in the background
+	p = Polling("polling-test-zero", 0, func(context.Context) ([]Item, error) { return []Item{NewItem("polling-zero", "v", 0)}, nil })
+	l, err := p.Items()
+	assert.NoError(err)
+	assert.Len(l.Items, 1)
+	assert.NoError(UnregisterProvider("polling-test-zero"))
+}
+
+func TestLifecycle(t *testing.T) {
+	assert := assert.New(t)
+
+	// overriding a provider closes the previous one
+	AllowProviderOverride()
+	overridden := InMemory("lifecycle-test-override")
+	InMemory("lifecycle-test-override")
+	select {
+	case <-overridden.Done():
+	default:
+		t.Error("overridden provider was not closed")
+	}
+	assert.NoError(UnregisterProvider("lifecycle-test-override"))
+
+	inmem := InMemory("lifecycle-test").Add(NewItem("lifecycle", "value", 0))
+	w := Watch()
+
+	assert.NoError(UnregisterProvider("lifecycle-test"))
+	select {
+	case <-inmem.Done():
+	default:
+		t.Error("provider was not closed")
+	}
+	select {
+	case <-w:
+	default:
+		t.Error("watchers were not notified")
+	}
+	_, err := GetItem("lifecycle")
+	assert.Equal(errors.Is(err, ErrNotFound), true)
+	assert.Equal(errors.Is(UnregisterProvider("lifecycle-test"), ErrProviderFail), true)
+
+	other := InMemory("lifecycle-test-2").Add(NewItem("lifecycle", "value", 0))
+
+	// a retry waiting for its next attempt holds the providers: shutdown interrupts it
+	failed := make(chan struct{}, 1)
+	RegisterProvider("lifecycle-test-retry", WithRetry(func() (ItemList, error) {
+		return ItemList{}, errors.New("unavailable")
+	}, RetryPolicy{
+		InitialBackoff: time.Hour,
+		OnAttempt: func(int, error) {
+			select {
+			case failed <- struct{}{}:
+			default:
+			}
+		},
+	}))
+	read := make(chan error)
+	go func() {
+		_, err := GetItemList()
+		read <- err
+	}()
+	select {
+	case <-failed:
+	case err := <-read:
+		t.Fatalf("retry did not wait: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(Shutdown(ctx))
+	assert.EqualError(errors.Unwrap(<-read), "unavailable")
+	assert.NoError(UnregisterProvider("lifecycle-test-retry"))
+	<-other.Done()
+	_, ok := <-w
+	assert.False(ok)
+	assert.Equal("value", must(GetItemValue("lifecycle")))
 }
 
 func mustValue(i Item) string {
@@ -175,7 +724,3 @@ func must(i interface{}, err error) interface{} {
 	}
 	return i
 }
-
-func mustType(a interface{}, b interface{}) bool {
-	return reflect.TypeOf(a) == reflect.TypeOf(b)
-}
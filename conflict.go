@@ -0,0 +1,70 @@
+package configstore
+
+import "sort"
+
+// TieBreak decides between two items sharing the same key and priority, see SquashWith.
+// It returns true if a should be preferred over b.
+type TieBreak func(a, b *Item) bool
+
+// TieBreakRegistrationOrder prefers the items coming from the most recently registered provider.
+func TieBreakRegistrationOrder(a, b *Item) bool {
+	pMut.Lock()
+	defer pMut.Unlock()
+	return providerSeq[a.provider] > providerSeq[b.provider]
+}
+
+// TieBreakLexical prefers the items coming from the provider with the lexically greatest name
+// (e.g. "file:/etc/conf.d/20-app.yml" over "file:/etc/conf.d/10-defaults.yml").
+func TieBreakLexical(a, b *Item) bool {
+	return a.provider > b.provider
+}
+
+// TieBreakProviderRank prefers the items coming from the provider appearing first in the given list of names.
+// Providers absent from the list come last.
+func TieBreakProviderRank(providerNames ...string) TieBreak {
+	rank := map[string]int{}
+	for i, n := range providerNames {
+		rank[n] = len(providerNames) - i
+	}
+	return func(a, b *Item) bool {
+		return rank[a.provider] > rank[b.provider]
+	}
+}
+
+// Conflict describes a key for which several items share the highest priority, with different values.
+type Conflict struct {
+	Key      string
+	Layer    Layer
+	Priority int64
+	Items    []Item
+}
+
+// Conflicts retrieves the full item list, merging the results from all providers, then lists its conflicts.
+// See ItemList.Conflicts.
+func Conflicts() ([]Conflict, error) {
+	items, err := GetItemList()
+	if err != nil {
+		return nil, err
+	}
+	return items.Conflicts(), nil
+}
+
+// Conflicts lists the keys for which several items share the highest priority, with different values.
+// Those keys are ambiguous: GetItem fails on them even after Squash, unless a tie-break is used (see SquashWith).
+func (s *ItemList) Conflicts() []Conflict {
+	if s == nil {
+		return nil
+	}
+	ret := []Conflict{}
+	for _, k := range s.Keys() {
+		highest := highestPriority(s.indexed[k])
+		for _, sec := range highest[1:] {
+			if sec.value != highest[0].value {
+				ret = append(ret, Conflict{Key: k, Layer: highest[0].layer, Priority: highest[0].priority, Items: append([]Item{}, highest...)})
+				break
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Key < ret[j].Key })
+	return ret
+}
@@ -0,0 +1,142 @@
+package configstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ConsulAddrEnvVar defines the environment variable used to set the Consul agent address (default http://127.0.0.1:8500).
+	ConsulAddrEnvVar = "CONSUL_HTTP_ADDR"
+	// ConsulTokenEnvVar defines the environment variable used to set the Consul ACL token.
+	ConsulTokenEnvVar = "CONSUL_HTTP_TOKEN"
+)
+
+// ConsulOptions customizes the Consul provider, see ConsulCustom.
+type ConsulOptions struct {
+	// Address of the Consul agent (default http://127.0.0.1:8500).
+	Address string
+	// Token is the ACL token sent with each request.
+	Token string
+	// Datacenter to query (default: the agent's).
+	Datacenter string
+	// Wait is the maximum duration of a blocking query (default 5m).
+	Wait time.Duration
+	// Client used for the requests (default: a client with a timeout slightly longer than Wait).
+	Client *http.Client
+}
+
+// consulKV is an entry of the Consul KV API. Value is base64 encoded in JSON, null for folders.
+type consulKV struct {
+	Key   string
+	Flags uint64
+	Value []byte
+}
+
+// Consul registers a configstore provider which reads the Consul KV entries under the prefix given in parameter.
+// The prefix is a path: "app" reads "app/..." but not "apps/...".
+// Each entry becomes an item: the key is the entry path relative to the prefix, the priority is the entry flags.
+// Changes are detected via blocking queries, and watchers get notified.
+// The agent address and ACL token are read from the environment, see ConsulAddrEnvVar and ConsulTokenEnvVar.
+func Consul(prefix string) {
+	ConsulCustom(prefix, ConsulOptions{
+		Address: os.Getenv(ConsulAddrEnvVar),
+		Token:   os.Getenv(ConsulTokenEnvVar),
+	})
+}
+
+// ConsulCustom is similar to Consul, with explicit options instead of the environment.
+func ConsulCustom(prefix string, opts ConsulOptions) *InMemoryProvider {
+	if opts.Address == "" {
+		opts.Address = "http://127.0.0.1:8500"
+	}
+	if !strings.Contains(opts.Address, "://") {
+		opts.Address = "http://" + opts.Address
+	}
+	if opts.Wait <= 0 {
+		opts.Wait = 5 * time.Minute
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: opts.Wait + opts.Wait/16 + 10*time.Second}
+	}
+	prefix = strings.TrimPrefix(prefix, "/")
+
+	index := uint64(0)
+	var last []Item
+
+	fetch := func(ctx context.Context) ([]Item, error) {
+		q := url.Values{}
+		q.Set("recurse", "true")
+		if opts.Datacenter != "" {
+			q.Set("dc", opts.Datacenter)
+		}
+		if index > 0 {
+			q.Set("index", strconv.FormatUint(index, 10))
+			q.Set("wait", fmt.Sprintf("%ds", int(opts.Wait.Seconds())))
+		}
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v1/kv/%s?%s", strings.TrimSuffix(opts.Address, "/"), prefix, q.Encode()), nil)
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+		if opts.Token != "" {
+			req.Header.Set("X-Consul-Token", opts.Token)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		entries := []consulKV{}
+		switch resp.StatusCode {
+		case http.StatusOK:
+			err = json.NewDecoder(resp.Body).Decode(&entries)
+			if err != nil {
+				return nil, err
+			}
+		case http.StatusNotFound:
+			// no entry under the prefix
+		default:
+			return nil, fmt.Errorf("unexpected HTTP status: %s", resp.Status)
+		}
+
+		newIndex, err := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+		if err != nil || newIndex < index {
+			// the index went backwards, or is missing: start over
+			newIndex = 0
+		}
+		if index > 0 && newIndex == index {
+			// blocking query timed out, nothing changed
+			return last, nil
+		}
+		index = newIndex
+
+		items := []Item{}
+		for _, e := range entries {
+			if strings.HasSuffix(e.Key, "/") && e.Value == nil {
+				// folder
+				continue
+			}
+			if prefix != "" && e.Key != prefix && !strings.HasPrefix(e.Key, strings.TrimSuffix(prefix, "/")+"/") {
+				// sibling sharing the prefix, e.g. "apps/x" for prefix "app"
+				continue
+			}
+			key := strings.TrimPrefix(strings.TrimPrefix(e.Key, prefix), "/")
+			items = append(items, NewItem(key, string(e.Value), int64(e.Flags)))
+		}
+		last = items
+		return items, nil
+	}
+
+	return Polling(fmt.Sprintf("consul:%s", prefix), time.Second, fetch)
+}
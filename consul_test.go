@@ -0,0 +1,113 @@
+package configstore
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConsul emulates the recursive read and the blocking queries of the Consul KV API.
+type fakeConsul struct {
+	mut     sync.Mutex
+	cond    *sync.Cond
+	index   uint64
+	entries map[string]consulKV
+}
+
+func newFakeConsul() *fakeConsul {
+	f := &fakeConsul{index: 1, entries: map[string]consulKV{}}
+	f.cond = sync.NewCond(&f.mut)
+	return f
+}
+
+func (f *fakeConsul) put(key, value string, flags uint64) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.index++
+	f.entries[key] = consulKV{Key: key, Value: []byte(value), Flags: flags}
+	f.cond.Broadcast()
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Consul-Token") != "token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	index, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+	wait, _ := time.ParseDuration(r.URL.Query().Get("wait"))
+
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	if index > 0 {
+		timeout := time.AfterFunc(wait, func() {
+			f.mut.Lock()
+			f.cond.Broadcast()
+			f.mut.Unlock()
+		})
+		defer timeout.Stop()
+		start := time.Now()
+		for f.index == index && time.Since(start) < wait {
+			f.cond.Wait()
+		}
+	}
+
+	entries := []consulKV{}
+	for k, e := range f.entries {
+		if strings.HasPrefix(k, prefix) {
+			entries = append(entries, e)
+		}
+	}
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+	if len(entries) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(entries)
+}
+
+func TestConsul(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := newFakeConsul()
+	fake.entries["app/"] = consulKV{Key: "app/"} // folder
+	fake.put("app/db/url", "postgres://db", 3)
+	fake.put("other/key", "other", 0)
+	fake.put("apps/key", "sibling", 0)
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	os.Setenv(ConsulAddrEnvVar, srv.URL)
+	os.Setenv(ConsulTokenEnvVar, "token")
+	defer os.Unsetenv(ConsulAddrEnvVar)
+	defer os.Unsetenv(ConsulTokenEnvVar)
+
+	w := Watch()
+	Consul("app")
+	defer UnregisterProvider("consul:app")
+
+	items, err := Filter().Slice("db/url").GetItemList()
+	assert.NoError(err)
+	assert.Len(items.Items, 1)
+	assert.Equal("postgres://db", mustValue(items.Items[0]))
+	assert.Equal(int64(3), items.Items[0].Priority())
+	items, err = Filter().Slice("s/key").GetItemList()
+	assert.NoError(err)
+	assert.Len(items.Items, 0)
+
+	fake.put("app/db/url", "postgres://new", 3)
+	select {
+	case <-w:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchers were not notified")
+	}
+	assert.Equal("postgres://new", must(GetItemValue("db/url")))
+}
@@ -1,22 +1,136 @@
 package configstore
 
-type ErrItemNotFound string
-type ErrUninitializedItemList string
-type ErrAmbiguousItem string
-type ErrProvider string
+import (
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors, to be used with errors.Is.
+// Any error of the corresponding type matches, whatever its fields.
+// Use errors.As to inspect the fields (key, provider, cause).
+var (
+	ErrNotFound      error = ErrItemNotFound{}
+	ErrUninitialized error = ErrUninitializedItemList{}
+	ErrAmbiguous     error = ErrAmbiguousItem{}
+	ErrProviderFail  error = ErrProvider{}
+	ErrInvalid       error = ErrInvalidItem{}
+)
+
+// ErrItemNotFound is returned when no item matches the requested key.
+type ErrItemNotFound struct {
+	Key string
+	// describes the failing operation, defaults to "get '<key>'"
+	op string
+}
+
+// ErrUninitializedItemList is returned when looking up an item in a nil item list.
+type ErrUninitializedItemList struct {
+	Key string
+}
+
+// ErrAmbiguousItem is returned when several items match the requested key.
+type ErrAmbiguousItem struct {
+	Key   string
+	Count int
+}
+
+// ErrProvider is returned when a provider fails. The provider error is available via errors.Unwrap.
+type ErrProvider struct {
+	Provider string
+	// describes the failing operation, empty when retrieving items
+	Op  string
+	Err error
+}
+
+// ErrMulti aggregates several errors, see ItemList.Errors.
+// It supports errors.Is/As on each of the aggregated errors.
+type ErrMulti []error
+
+// ErrInvalidItem is stored within items whose unmarshaled object does not respect its `validate:"..."` struct tags,
+// or whose Validate method fails (see Validator); Field is empty in the latter case.
+// The rule violation is available via errors.Unwrap.
+type ErrInvalidItem struct {
+	Key   string
+	Field string
+	Err   error
+}
 
 func (e ErrItemNotFound) Error() string {
-	return string(e)
+	op := e.op
+	if op == "" {
+		op = fmt.Sprintf("get '%s'", e.Key)
+	}
+	return fmt.Sprintf("configstore: %s: no item found", op)
+}
+
+// Is matches any ErrItemNotFound, see ErrNotFound.
+func (e ErrItemNotFound) Is(target error) bool {
+	_, ok := target.(ErrItemNotFound)
+	return ok
 }
 
 func (e ErrUninitializedItemList) Error() string {
-	return string(e)
+	return fmt.Sprintf("configstore: get '%s': non-initialized item list", e.Key)
+}
+
+// Is matches any ErrUninitializedItemList, see ErrUninitialized.
+func (e ErrUninitializedItemList) Is(target error) bool {
+	_, ok := target.(ErrUninitializedItemList)
+	return ok
 }
 
 func (e ErrAmbiguousItem) Error() string {
-	return string(e)
+	return fmt.Sprintf("configstore: get '%s': ambiguous, %d items share that key", e.Key, e.Count)
+}
+
+// Is matches any ErrAmbiguousItem, see ErrAmbiguous.
+func (e ErrAmbiguousItem) Is(target error) bool {
+	_, ok := target.(ErrAmbiguousItem)
+	return ok
 }
 
 func (e ErrProvider) Error() string {
-	return string(e)
+	if e.Op != "" {
+		return fmt.Sprintf("configstore: %s provider '%s': %s", e.Op, e.Provider, e.Err)
+	}
+	return fmt.Sprintf("configstore: provider '%s': %s", e.Provider, e.Err)
+}
+
+// Is matches any ErrProvider, see ErrProviderFail.
+func (e ErrProvider) Is(target error) bool {
+	_, ok := target.(ErrProvider)
+	return ok
+}
+
+func (e ErrProvider) Unwrap() error {
+	return e.Err
+}
+
+func (e ErrMulti) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e ErrMulti) Unwrap() []error {
+	return e
+}
+
+func (e ErrInvalidItem) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("configstore: validate '%s': %s", e.Key, e.Err)
+	}
+	return fmt.Sprintf("configstore: validate '%s': field '%s': %s", e.Key, e.Field, e.Err)
+}
+
+// Is matches any ErrInvalidItem, see ErrInvalid.
+func (e ErrInvalidItem) Is(target error) bool {
+	_, ok := target.(ErrInvalidItem)
+	return ok
+}
+
+func (e ErrInvalidItem) Unwrap() error {
+	return e.Err
 }
@@ -0,0 +1,220 @@
+// Package etcdstore provides a configstore provider reading its items from etcd v3.
+//
+// Importing the package registers the "etcd" provider factory, see InitFromEnvironment:
+//
+//	CONFIGURATION_FROM=etcd:/myapp/config/
+package etcdstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ovh/configstore"
+	"github.com/sirupsen/logrus"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const (
+	// EndpointsEnvVar defines the environment variable used to set the etcd endpoints of the "etcd" provider factory,
+	// comma separated (default localhost:2379).
+	EndpointsEnvVar = "ETCDCTL_ENDPOINTS"
+)
+
+func init() {
+	configstore.RegisterProviderFactory("etcd", factory)
+}
+
+func factory(prefix string) {
+	name := fmt.Sprintf("etcd:%s", prefix)
+	endpoints := []string{"localhost:2379"}
+	if e := os.Getenv(EndpointsEnvVar); e != "" {
+		endpoints = strings.Split(e, ",")
+	}
+	client, err := clientv3.New(clientv3.Config{Endpoints: endpoints, DialTimeout: 5 * time.Second})
+	if err != nil {
+		configstore.ErrorProvider(name, err)
+		return
+	}
+	w := watch(name, client, prefix)
+	// the client is owned by the provider
+	configstore.RegisterProviderCloser(name, clientCloser{w})
+}
+
+type clientCloser struct {
+	w *watcher
+}
+
+// Stops watching before closing the client, so that the watch loop does not see a closed client.
+func (c clientCloser) Close() error {
+	c.w.cancel()
+	c.w.inmem.Close()
+	return c.w.client.Close()
+}
+
+// Provider registers a configstore provider which reads the keys under prefix, and watches them for changes.
+// Each key becomes an item, keyed by its path relative to prefix, with priority 0.
+// The in-memory copy is updated on each watch event, and watchers get notified.
+// The watch resumes from the last seen revision if it breaks, so that no change is missed; if that revision
+// got compacted, the keys are read again.
+// The first read happens synchronously. Watching stops when the provider is closed (see configstore.UnregisterProvider).
+func Provider(name string, client *clientv3.Client, prefix string) *configstore.InMemoryProvider {
+	return watch(name, client, prefix).inmem
+}
+
+// Registers the provider, and starts watching.
+func watch(name string, client *clientv3.Client, prefix string) *watcher {
+	w := &watcher{
+		inmem:  configstore.InMemory(name),
+		client: client,
+		prefix: prefix,
+		name:   name,
+		kvs:    map[string]string{},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	go func() {
+		<-w.inmem.Done()
+		cancel()
+	}()
+
+	err := w.load(ctx)
+	if err != nil {
+		logrus.Errorf("configstore: provider '%s': %s", name, err)
+		w.inmem.SetError(err)
+	}
+	go w.run(ctx)
+
+	return w
+}
+
+type watcher struct {
+	inmem  *configstore.InMemoryProvider
+	client *clientv3.Client
+	prefix string
+	name   string
+	cancel context.CancelFunc
+
+	mut sync.Mutex
+	kvs map[string]string
+	// last revision seen
+	rev int64
+}
+
+// Reads all the keys under the prefix.
+func (w *watcher) load(ctx context.Context) error {
+	resp, err := w.client.Get(ctx, w.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return err
+	}
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	w.kvs = map[string]string{}
+	for _, kv := range resp.Kvs {
+		w.kvs[string(kv.Key)] = string(kv.Value)
+	}
+	w.rev = resp.Header.Revision
+	w.publish()
+	return nil
+}
+
+// Watches the keys under the prefix until ctx is done or the client is closed, resuming after the last seen revision.
+func (w *watcher) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		if w.client.Ctx().Err() != nil {
+			// a closed client only returns closed watch channels
+			logrus.Errorf("configstore: provider '%s': client closed, watch stopped", w.name)
+			return
+		}
+
+		w.mut.Lock()
+		rev := w.rev
+		w.mut.Unlock()
+
+		if rev == 0 {
+			// initial load failed
+			err := w.load(ctx)
+			if err != nil {
+				w.retry(ctx, err)
+				continue
+			}
+			configstore.NotifyWatchers()
+			continue
+		}
+
+		closed := true
+		for resp := range w.client.Watch(clientv3.WithRequireLeader(ctx), w.prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1)) {
+			if resp.CompactRevision != 0 {
+				closed = false
+				// missed changes: read everything again
+				err := w.load(ctx)
+				if err != nil {
+					w.retry(ctx, err)
+				} else {
+					configstore.NotifyWatchers()
+				}
+				break
+			}
+			err := resp.Err()
+			if err != nil {
+				closed = false
+				w.retry(ctx, err)
+				break
+			}
+			if len(resp.Events) == 0 {
+				continue
+			}
+			w.mut.Lock()
+			for _, ev := range resp.Events {
+				switch ev.Type {
+				case clientv3.EventTypePut:
+					w.kvs[string(ev.Kv.Key)] = string(ev.Kv.Value)
+				case clientv3.EventTypeDelete:
+					delete(w.kvs, string(ev.Kv.Key))
+				}
+			}
+			w.rev = resp.Header.Revision
+			w.publish()
+			w.mut.Unlock()
+			configstore.NotifyWatchers()
+		}
+		if closed {
+			// the channel closed without a response telling why: do not spin on it
+			w.retry(ctx, errors.New("watch closed"))
+		}
+	}
+}
+
+// Logs err and waits before the next attempt, unless ctx is done or the client is closed.
+func (w *watcher) retry(ctx context.Context, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	logrus.Errorf("configstore: provider '%s': %s", w.name, err)
+	select {
+	case <-time.After(time.Second):
+	case <-ctx.Done():
+	case <-w.client.Ctx().Done():
+	}
+}
+
+// Replaces the in-memory items. NOT CONCURRENT SAFE, w.mut must be held.
+func (w *watcher) publish() {
+	keys := make([]string, 0, len(w.kvs))
+	for k := range w.kvs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	items := make([]configstore.Item, 0, len(keys))
+	for _, k := range keys {
+		key := strings.TrimPrefix(strings.TrimPrefix(k, w.prefix), "/")
+		items = append(items, configstore.NewItem(key, w.kvs[k], 0))
+	}
+	w.inmem.Set(items...)
+}
@@ -0,0 +1,224 @@
+package etcdstore
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ovh/configstore"
+	"github.com/stretchr/testify/assert"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+func startEtcd(t *testing.T) *embed.Etcd {
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	u, _ := url.Parse("http://127.0.0.1:0")
+	cfg.ListenClientUrls = []url.URL{*u}
+	cfg.ListenPeerUrls = []url.URL{*u}
+	e, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-e.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		e.Close()
+		t.Fatal("etcd did not start")
+	}
+	return e
+}
+
+func TestProvider(t *testing.T) {
+	assert := assert.New(t)
+
+	e := startEtcd(t)
+	defer e.Close()
+
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{e.Clients[0].Addr().String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Put(ctx, "/app/db/url", "postgres://db")
+	assert.NoError(err)
+	_, err = client.Put(ctx, "/other", "other")
+	assert.NoError(err)
+
+	w := configstore.Watch()
+	Provider("etcd-test", client, "/app/")
+	defer configstore.UnregisterProvider("etcd-test")
+
+	v, err := configstore.GetItemValue("db/url")
+	assert.NoError(err)
+	assert.Equal("postgres://db", v)
+	_, err = configstore.GetItem("other")
+	assert.Error(err)
+
+	waitFor := func(f func() bool) {
+		deadline := time.After(5 * time.Second)
+		for !f() {
+			select {
+			case <-w:
+			case <-deadline:
+				t.Fatal("watchers were not notified")
+			}
+		}
+	}
+
+	_, err = client.Put(ctx, "/app/db/url", "postgres://new")
+	assert.NoError(err)
+	waitFor(func() bool {
+		v, _ := configstore.GetItemValue("db/url")
+		return v == "postgres://new"
+	})
+
+	_, err = client.Delete(ctx, "/app/db/url")
+	assert.NoError(err)
+	waitFor(func() bool {
+		_, err := configstore.GetItem("db/url")
+		return err != nil
+	})
+}
+
+// dropWatcher holds the first watch of the provider until it gets dropped, later watches reach etcd.
+type dropWatcher struct {
+	clientv3.Watcher
+	mut    sync.Mutex
+	calls  int
+	first  chan clientv3.WatchResponse
+	called chan struct{}
+}
+
+func newDropWatcher(client *clientv3.Client) *dropWatcher {
+	d := &dropWatcher{Watcher: client.Watcher, first: make(chan clientv3.WatchResponse), called: make(chan struct{})}
+	client.Watcher = d
+	return d
+}
+
+func (d *dropWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	d.mut.Lock()
+	d.calls++
+	n := d.calls
+	d.mut.Unlock()
+	if n == 1 {
+		close(d.called)
+		return d.first
+	}
+	return d.Watcher.Watch(ctx, key, opts...)
+}
+
+// Starts a provider on /resume/ whose first watch is held by a dropWatcher, changes key while it is held,
+// calls beforeDrop, then drops the watch. Returns the notification channel.
+func testDroppedWatch(t *testing.T, name string, key string, beforeDrop func(client *clientv3.Client)) <-chan struct{} {
+	e := startEtcd(t)
+	t.Cleanup(e.Close)
+
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{e.Clients[0].Addr().String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	d := newDropWatcher(client)
+
+	ctx := context.Background()
+	if _, err := client.Put(ctx, "/resume/"+key, "v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	w := configstore.Watch()
+	t.Cleanup(func() { configstore.Unwatch(w) })
+	Provider(name, client, "/resume/")
+	t.Cleanup(func() { configstore.UnregisterProvider(name) })
+
+	<-d.called
+	// missed by the held watch
+	if _, err := client.Put(ctx, "/resume/"+key, "v2"); err != nil {
+		t.Fatal(err)
+	}
+	v, _ := configstore.GetItemValue(key)
+	assert.Equal(t, "v1", v)
+
+	if beforeDrop != nil {
+		beforeDrop(client)
+	}
+	close(d.first)
+	return w
+}
+
+func waitForValue(t *testing.T, w <-chan struct{}, key, expected string) {
+	deadline := time.After(5 * time.Second)
+	for {
+		v, _ := configstore.GetItemValue(key)
+		if v == expected {
+			return
+		}
+		select {
+		case <-w:
+		case <-deadline:
+			t.Fatalf("%s: expected %s, got %s", key, expected, v)
+		}
+	}
+}
+
+func TestProviderResume(t *testing.T) {
+	// the watch resumes after the last seen revision: the change made while it was down is delivered
+	w := testDroppedWatch(t, "etcd-test-resume", "resumed", nil)
+	waitForValue(t, w, "resumed", "v2")
+}
+
+func TestProviderCompaction(t *testing.T) {
+	// the revisions following the last seen one got compacted: the keys are read again
+	w := testDroppedWatch(t, "etcd-test-compaction", "compacted", func(client *clientv3.Client) {
+		ctx := context.Background()
+		resp, err := client.Put(ctx, "/resume/compacted", "v3")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Compact(ctx, resp.Header.Revision); err != nil {
+			t.Fatal(err)
+		}
+	})
+	waitForValue(t, w, "compacted", "v3")
+}
+
+// countingWatcher counts the watches of the provider.
+type countingWatcher struct {
+	clientv3.Watcher
+	calls int32
+}
+
+func (c *countingWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	atomic.AddInt32(&c.calls, 1)
+	return c.Watcher.Watch(ctx, key, opts...)
+}
+
+func TestProviderClientClosed(t *testing.T) {
+	e := startEtcd(t)
+	defer e.Close()
+
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{e.Clients[0].Addr().String()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &countingWatcher{Watcher: client.Watcher}
+	client.Watcher = c
+
+	Provider("etcd-test-closed", client, "/closed/")
+	defer configstore.UnregisterProvider("etcd-test-closed")
+
+	// a closed client returns closed watch channels: the provider stops watching instead of spinning
+	client.Close()
+	time.Sleep(200 * time.Millisecond)
+	calls := atomic.LoadInt32(&c.calls)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, calls, atomic.LoadInt32(&c.calls))
+	assert.True(t, calls <= 2)
+}
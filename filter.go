@@ -3,6 +3,9 @@ package configstore
 import (
 	"encoding/json"
 	"fmt"
+	"path"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -10,11 +13,14 @@ import (
 // It can be declared globally then used/applied on specific item lists later.
 // By declaring it before actual use, you can make it available to other packages
 // which can then use it to describe your filters / configuration (e.g. main for usage).
-// See String().
+// See String(), RegisterFilter() and Usage().
 type ItemFilter struct {
 	funcs           []func(*ItemList) *ItemList
 	initialKeySlice string
-	unmarshalType   interface{}
+	// initialKeySlice is a pattern (SlicePrefix, SliceGlob, ...) rather than an exact key
+	initialKeyPattern bool
+	unmarshalType     interface{}
+	defaultValue      *string
 }
 
 // Filter creates a new empty filter object.
@@ -28,13 +34,16 @@ func (s *ItemFilter) String() string {
 		return ""
 	}
 
-	typeStr := "string"
-	if s.unmarshalType != nil {
-		j, _ := json.Marshal(s.unmarshalType)
-		typeStr = string(j)
-	}
+	return fmt.Sprintf("%s: %s", s.initialKeySlice, s.typeString())
+}
 
-	return fmt.Sprintf("%s: %s", s.initialKeySlice, typeStr)
+// Returns the expected value type: "string", or the JSON skeleton of the Unmarshal factory object.
+func (s *ItemFilter) typeString() string {
+	if s == nil || s.unmarshalType == nil {
+		return "string"
+	}
+	j, _ := json.Marshal(s.unmarshalType)
+	return string(j)
 }
 
 /*
@@ -72,7 +81,7 @@ func (s *ItemFilter) GetFirstItem() (Item, error) {
 		if sliceKey == "" {
 			sliceKey = "[NONE]"
 		}
-		return Item{}, ErrItemNotFound(fmt.Sprintf("configstore: get first item (slice: %s): no item found", sliceKey))
+		return Item{}, ErrItemNotFound{Key: s.initialKeySlice, op: fmt.Sprintf("get first item (slice: %s)", sliceKey)}
 	}
 	return items.Items[0], nil
 }
@@ -160,6 +169,9 @@ func (s *ItemFilter) Apply(items *ItemList) *ItemList {
 	for _, f := range s.funcs {
 		filtered = f(filtered)
 	}
+	if filtered != nil && items != nil {
+		filtered.errors = items.errors
+	}
 	return filtered
 }
 
@@ -175,6 +187,8 @@ func copyItemFilter(s *ItemFilter) *ItemFilter {
 		ret.funcs = s.funcs
 		ret.unmarshalType = s.unmarshalType
 		ret.initialKeySlice = s.initialKeySlice
+		ret.initialKeyPattern = s.initialKeyPattern
+		ret.defaultValue = s.defaultValue
 	}
 	return ret
 }
@@ -202,6 +216,86 @@ func (s *ItemFilter) Slice(key string, keyF ...func(string) string) *ItemFilter
 	return s
 }
 
+// Default provides a fallback value: if the list is empty when this step is applied, it will contain
+// a single item, keyed by the initial key passed to Slice, holding the given value.
+// The value also shows up in the usage description of the filter (see Usage).
+// Filters starting with a pattern (SlicePrefix, SliceGlob, SliceRegexp) have no key to give to the fallback item:
+// the value only shows up in the usage description.
+func (s *ItemFilter) Default(value string) *ItemFilter {
+
+	s = copyItemFilter(s)
+
+	if s.defaultValue == nil {
+		s.defaultValue = &value
+	}
+	key, pattern := s.initialKeySlice, s.initialKeyPattern
+
+	s.funcs = append(s.funcs, func(s *ItemList) *ItemList {
+		if len(s.Items) > 0 || pattern {
+			return s
+		}
+		return (&ItemList{Items: []Item{NewItem(key, value, 0)}}).index()
+	})
+
+	return s
+}
+
+// SlicePrefix filters the list items, keeping only those whose key starts with prefix.
+// Combined with hierarchical keys (e.g. "database.host", "database.port"), it selects a whole sub-tree.
+func (s *ItemFilter) SlicePrefix(prefix string) *ItemFilter {
+	return s.sliceFunc(prefix+"*", func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+// SubTree is similar to SlicePrefix, but also strips the prefix from the item keys.
+// A subsystem can then receive a sub-tree of the configuration as if it were its own root.
+func (s *ItemFilter) SubTree(prefix string) *ItemFilter {
+	return s.SlicePrefix(prefix).Rekey(func(i *Item) string {
+		return strings.TrimPrefix(i.Key(), prefix)
+	})
+}
+
+// SliceGlob filters the list items, keeping only those whose key matches the shell pattern.
+// See path.Match for the pattern syntax. Globbing only understands '/' as a separator:
+// '*' and '?' do not match '/', but they do match '.', so "db.*" matches "db.a.b" while "db/*" does not match "db/a/b".
+// Use SlicePrefix or SliceRegexp for finer control over dotted keys.
+// An invalid pattern matches no item.
+func (s *ItemFilter) SliceGlob(pattern string) *ItemFilter {
+	return s.sliceFunc(pattern, func(key string) bool {
+		ok, _ := path.Match(pattern, key)
+		return ok
+	})
+}
+
+// SliceRegexp filters the list items, keeping only those whose key matches the regular expression.
+func (s *ItemFilter) SliceRegexp(re *regexp.Regexp) *ItemFilter {
+	return s.sliceFunc(re.String(), re.MatchString)
+}
+
+// Implementation of the key matching logic for SlicePrefix/SliceGlob/... public functions
+func (s *ItemFilter) sliceFunc(desc string, matchF func(string) bool) *ItemFilter {
+
+	s = copyItemFilter(s)
+
+	if s.initialKeySlice == "" {
+		s.initialKeySlice = desc
+		s.initialKeyPattern = true
+	}
+
+	s.funcs = append(s.funcs, func(s *ItemList) *ItemList {
+		ret := &ItemList{Items: []Item{}}
+		for _, sec := range s.Items {
+			if matchF(sec.key) {
+				ret.Items = append(ret.Items, sec)
+			}
+		}
+		return ret.index()
+	})
+
+	return s
+}
+
 // Rekey modifies item keys. The function parameter is called for each item in the item list, and the returned string
 // is used as the new key.
 func (s *ItemFilter) Rekey(rekeyF func(*Item) string) *ItemFilter {
@@ -212,6 +306,11 @@ func (s *ItemFilter) Rekey(rekeyF func(*Item) string) *ItemFilter {
 			priority:     sec.priority,
 			unmarshaled:  sec.unmarshaled,
 			unmarshalErr: sec.unmarshalErr,
+			provider:     sec.provider,
+			layer:        sec.layer,
+			stale:        sec.stale,
+			sensitive:    sec.sensitive,
+			revision:     sec.revision,
 		}
 	})
 }
@@ -226,6 +325,11 @@ func (s *ItemFilter) Reorder(reorderF func(*Item) int64) *ItemFilter {
 			priority:     reorderF(sec),
 			unmarshaled:  sec.unmarshaled,
 			unmarshalErr: sec.unmarshalErr,
+			provider:     sec.provider,
+			layer:        sec.layer,
+			stale:        sec.stale,
+			sensitive:    sec.sensitive,
+			revision:     sec.revision,
 		}
 	})
 }
@@ -244,11 +348,27 @@ func (s *ItemFilter) Transform(transformF func(*Item) (string, error)) *ItemFilt
 			priority:     sec.priority,
 			unmarshaled:  sec.unmarshaled,
 			unmarshalErr: err,
+			provider:     sec.provider,
+			layer:        sec.layer,
+			stale:        sec.stale,
+			sensitive:    sec.sensitive,
+			revision:     sec.revision,
 		}
 	})
 }
 
+// Sensitive flags all the items in the item list as sensitive, so that their values get redacted when exposed (see Handler).
+// Use it after a Slice step to flag secrets from providers which do not flag them, e.g. Filter().SliceGlob("*password*").Sensitive().
+func (s *ItemFilter) Sensitive() *ItemFilter {
+	return s.mapFunc(func(sec *Item) Item {
+		ret := *sec
+		ret.sensitive = true
+		return ret
+	})
+}
+
 // Unmarshal tries to unmarshal (from JSON or YAML) all the items in the item list into objects returned by the factory f().
+// The objects are then validated, using their `validate:"..."` struct tags and their Validate() method if they implement Validator.
 // The results and errors will be stored to be handled later. See item.Unmarshaled().
 func (s *ItemFilter) Unmarshal(f func() interface{}) *ItemFilter {
 
@@ -293,25 +413,46 @@ func (s *ItemFilter) mapFunc(mapF func(*Item) Item) *ItemFilter {
 }
 
 // Squash filters the items in the item list, keeping only the items with the highest priority for each key.
+// Several items are kept for a key when they share the highest priority, see SquashWith to break the tie.
 func (s *ItemFilter) Squash() *ItemFilter {
+	return s.SquashWith(nil)
+}
+
+// SquashWith is similar to Squash, but keeps a single item for each key: when several items share the highest
+// priority, the tie-break function decides which one is kept. A nil tie-break keeps all of them.
+func (s *ItemFilter) SquashWith(tieBreak TieBreak) *ItemFilter {
 
 	s = copyItemFilter(s)
 
 	s.funcs = append(s.funcs, func(s *ItemList) *ItemList {
 		ret := &ItemList{}
 		for _, l := range s.indexed {
-			highest := int64(0)
-			if len(l) > 0 {
-				highest = l[0].priority
-			}
-			for _, sec := range l {
-				if sec.priority >= highest {
-					ret.Items = append(ret.Items, sec)
+			highest := highestPriority(l)
+			if tieBreak != nil && len(highest) > 1 {
+				best := highest[0]
+				for _, sec := range highest[1:] {
+					if tieBreak(&sec, &best) {
+						best = sec
+					}
 				}
+				highest = []Item{best}
 			}
+			ret.Items = append(ret.Items, highest...)
 		}
 		return ret.index()
 	})
 
 	return s
 }
+
+// Returns the items sharing the highest layer and priority, in a list sorted by descending layer and priority.
+func highestPriority(l []Item) []Item {
+	if len(l) == 0 {
+		return nil
+	}
+	i := 1
+	for i < len(l) && !l[0].outranks(&l[i]) {
+		i++
+	}
+	return l[:i]
+}
@@ -0,0 +1,158 @@
+package configstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// Flatten expands the items holding a (JSON or YAML) object value into one item per leaf field,
+// keyed by the path of the field joined with separator (e.g. "database" {"port": 5432} => "database.port" 5432).
+// Lists are considered leaves, and are kept as JSON. Priority and provider are preserved.
+// Items which do not hold an object, or which carry an error, are kept as is.
+func (s *ItemFilter) Flatten(separator string) *ItemFilter {
+
+	s = copyItemFilter(s)
+
+	s.funcs = append(s.funcs, func(s *ItemList) *ItemList {
+		ret := &ItemList{}
+		for _, sec := range s.Items {
+			var v interface{}
+			if sec.unmarshalErr != nil || yaml.Unmarshal([]byte(sec.value), &v) != nil {
+				ret.Items = append(ret.Items, sec)
+				continue
+			}
+			m, ok := v.(map[string]interface{})
+			if !ok || len(m) == 0 {
+				ret.Items = append(ret.Items, sec)
+				continue
+			}
+			ret.Items = flattenValue(ret.Items, sec, sec.key, m, separator)
+		}
+		return ret.index()
+	})
+
+	return s
+}
+
+func flattenValue(items []Item, orig Item, key string, v interface{}, separator string) []Item {
+	if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+		fields := make([]string, 0, len(m))
+		for f := range m {
+			fields = append(fields, f)
+		}
+		sort.Strings(fields)
+		for _, f := range fields {
+			items = flattenValue(items, orig, key+separator+f, m[f], separator)
+		}
+		return items
+	}
+
+	value := ""
+	var err error
+	switch val := v.(type) {
+	case nil:
+	case string:
+		value = val
+	default:
+		var j []byte
+		j, err = json.Marshal(val)
+		value = string(j)
+	}
+	return append(items, Item{key: key, value: value, priority: orig.priority, unmarshalErr: err, provider: orig.provider, layer: orig.layer, stale: orig.stale, sensitive: orig.sensitive, revision: orig.revision})
+}
+
+// Nest is the inverse of Flatten: it rebuilds object values from the items whose keys contain separator.
+// "database.host" and "database.port" become a single "database" item holding {"host": ..., "port": ...} as JSON.
+// Leaf values are parsed as JSON, so that numbers and booleans keep their type; anything else is kept as a string.
+// When several items share the same key, the highest priority one is used. The nested item gets the highest
+// priority of its fields, and the provider of that field.
+// A key which is both a leaf and an object (e.g. "a.b" and "a.b.c") makes the nested item carry an ErrInvalidItem.
+// Items whose key does not contain separator, or which carry an error, are kept as is.
+func (s *ItemFilter) Nest(separator string) *ItemFilter {
+
+	s = copyItemFilter(s)
+
+	s.funcs = append(s.funcs, func(s *ItemList) *ItemList {
+		ret := &ItemList{}
+		nested := map[string]*Item{}
+		objects := map[string]map[string]interface{}{}
+		roots := []string{}
+
+		keys := make([]string, 0, len(s.indexed))
+		for k := range s.indexed {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			l := s.indexed[k]
+			if len(l) == 0 {
+				continue
+			}
+			// items are sorted by descending priority: keep the first one of each key
+			sec := l[0]
+			parts := strings.Split(sec.key, separator)
+			if len(parts) < 2 || sec.unmarshalErr != nil {
+				ret.Items = append(ret.Items, l...)
+				continue
+			}
+			root := parts[0]
+			obj, ok := objects[root]
+			if !ok {
+				obj = map[string]interface{}{}
+				objects[root] = obj
+				nested[root] = &Item{key: root, priority: sec.priority, provider: sec.provider, layer: sec.layer, revision: sec.revision}
+				roots = append(roots, root)
+			}
+			it := nested[root]
+			if sec.outranks(it) {
+				it.priority = sec.priority
+				it.provider = sec.provider
+				it.layer = sec.layer
+				it.revision = sec.revision
+			}
+			it.stale = it.stale || sec.stale
+			it.sensitive = it.sensitive || sec.sensitive
+			var v interface{}
+			if json.Unmarshal([]byte(sec.value), &v) != nil {
+				v = sec.value
+			}
+			for i, p := range parts[1 : len(parts)-1] {
+				child, ok := obj[p].(map[string]interface{})
+				if !ok {
+					if _, leaf := obj[p]; leaf && it.unmarshalErr == nil {
+						it.unmarshalErr = ErrInvalidItem{Key: root, Err: fmt.Errorf("%s is both a value and an object", strings.Join(parts[:i+2], separator))}
+					}
+					child = map[string]interface{}{}
+					obj[p] = child
+				}
+				obj = child
+			}
+			last := parts[len(parts)-1]
+			if _, ok := obj[last].(map[string]interface{}); ok {
+				if it.unmarshalErr == nil {
+					it.unmarshalErr = ErrInvalidItem{Key: root, Err: fmt.Errorf("%s is both a value and an object", sec.key)}
+				}
+				continue
+			}
+			obj[last] = v
+		}
+
+		for _, root := range roots {
+			it := nested[root]
+			j, err := json.Marshal(objects[root])
+			it.value = string(j)
+			if it.unmarshalErr == nil {
+				it.unmarshalErr = err
+			}
+			ret.Items = append(ret.Items, *it)
+		}
+		return ret.index()
+	})
+
+	return s
+}
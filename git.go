@@ -0,0 +1,134 @@
+package configstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// GitOptions customizes the Git provider, see GitCustom.
+type GitOptions struct {
+	// Ref to read: branch, tag or commit (default HEAD).
+	Ref string
+	// Path of the file, or directory, to read within the repository (default: the whole repository).
+	Path string
+	// Interval between two checks of the ref. If zero, the repository is read only once.
+	Interval time.Duration
+	// Unmarshal loads the content of each file (default: the same JSON/YAML item list format as File).
+	Unmarshal func([]byte) ([]Item, error)
+}
+
+// Git registers a configstore provider which reads item files from a local git repository, without checking out a worktree.
+// The parameter is "<repository>[@<ref>][:<path>]", e.g. "/srv/config.git@production:myapp/".
+// The files (all of them under path, recursively) use the same JSON/YAML item list format as File.
+// The ref is checked every 10s, and the files are read again when it moves; watchers get notified on change.
+func Git(param string) {
+	if param == "" {
+		return
+	}
+	repo, opts := param, GitOptions{Interval: 10 * time.Second}
+	if i := strings.LastIndex(repo, "@"); i >= 0 {
+		repo, opts.Ref = repo[:i], repo[i+1:]
+		if j := strings.Index(opts.Ref, ":"); j >= 0 {
+			opts.Ref, opts.Path = opts.Ref[:j], opts.Ref[j+1:]
+		}
+	} else if i := strings.LastIndex(repo, ":"); i >= 0 {
+		repo, opts.Path = repo[:i], repo[i+1:]
+	}
+	GitCustom(repo, opts)
+}
+
+// GitCustom is similar to Git, with explicit options. It requires the git command.
+// Each item exposes the hash of the commit it was read from, see Item.Revision.
+func GitCustom(repo string, opts GitOptions) *InMemoryProvider {
+	if opts.Ref == "" {
+		opts.Ref = "HEAD"
+	}
+	name := fmt.Sprintf("git:%s@%s", repo, opts.Ref)
+	if opts.Path != "" {
+		name = fmt.Sprintf("%s:%s", name, opts.Path)
+	}
+
+	commit := ""
+	var last []Item
+
+	fetch := func(ctx context.Context) ([]Item, error) {
+		out, err := git(ctx, repo, "rev-parse", "--verify", "--end-of-options", opts.Ref+"^{commit}")
+		if err != nil {
+			return nil, err
+		}
+		c := strings.TrimSpace(string(out))
+		if c == commit {
+			return last, nil
+		}
+		items, err := readGitItems(ctx, repo, c, opts)
+		if err != nil {
+			return nil, err
+		}
+		commit = c
+		last = items
+		return items, nil
+	}
+
+	if opts.Interval > 0 {
+		return Polling(name, opts.Interval, fetch)
+	}
+	return fetchOnce(name, fetch)
+}
+
+// Reads the items of the files under opts.Path at the given commit.
+func readGitItems(ctx context.Context, repo, commit string, opts GitOptions) ([]Item, error) {
+	args := []string{"ls-tree", "-r", "-z", commit}
+	if opts.Path != "" {
+		args = append(args, "--", opts.Path)
+	}
+	out, err := git(ctx, repo, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []Item{}
+	for _, entry := range strings.Split(string(out), "\x00") {
+		// <mode> SP <type> SP <object> TAB <file>
+		parts := strings.SplitN(entry, "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		fields := strings.Fields(parts[0])
+		if len(fields) != 3 || fields[1] != "blob" {
+			// e.g. submodules
+			continue
+		}
+		b, err := git(ctx, repo, "cat-file", "blob", fields[2])
+		if err != nil {
+			return nil, err
+		}
+		vals, err := unmarshalItems(b, opts.Unmarshal)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", parts[1], err)
+		}
+		for _, it := range vals {
+			it.revision = commit
+			items = append(items, it)
+		}
+	}
+	return items, nil
+}
+
+// Runs a git command in repo, returns its standard output.
+func git(ctx context.Context, repo string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repo}, args...)...)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %s", args[0], err)
+	}
+	return out, nil
+}
@@ -0,0 +1,124 @@
+package configstore
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gitCommit writes the files to the repository, commits them, and returns the commit hash.
+func gitCommit(t *testing.T, repo string, files map[string]string) string {
+	for name, content := range files {
+		p := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, repo, "add", "-A")
+	runGit(t, repo, "commit", "-q", "-m", "update")
+	return runGit(t, repo, "rev-parse", "HEAD")
+}
+
+func runGit(t *testing.T, repo string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %s: %s", args[0], err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestGit(t *testing.T) {
+	assert := assert.New(t)
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo, err := ioutil.TempDir("", "configstore-git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repo)
+
+	runGit(t, repo, "init", "-q", "-b", "main")
+	first := gitCommit(t, repo, map[string]string{
+		"README.md":         "not read",
+		"myapp/db.yml":      "- key: git.db\n  value: db1\n  priority: 5\n",
+		"myapp/nested/a.js": `[{"key": "git.nested", "value": "a"}]`,
+	})
+	runGit(t, repo, "tag", "v1")
+
+	p := GitCustom(repo, GitOptions{Ref: "main", Path: "myapp", Interval: 20 * time.Millisecond})
+	defer UnregisterProvider("git:" + repo + "@main:myapp")
+
+	l, err := p.Items()
+	assert.NoError(err)
+	if assert.Len(l.Items, 2) {
+		assert.Equal("git.db", l.Items[0].Key())
+		assert.Equal(int64(5), l.Items[0].Priority())
+		assert.Equal(first, l.Items[0].Revision())
+		assert.Equal("git.nested", l.Items[1].Key())
+	}
+
+	ch := Watch()
+	defer Unwatch(ch)
+
+	second := gitCommit(t, repo, map[string]string{
+		"myapp/db.yml": "- key: git.db\n  value: db2\n",
+	})
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("watchers not notified")
+	}
+	l, err = p.Items()
+	assert.NoError(err)
+	if assert.Len(l.Items, 2) {
+		assert.Equal("db2", mustValue(l.Items[0]))
+		assert.Equal(second, l.Items[0].Revision())
+	}
+
+	// the ref moves, the files do not change: the items report the new commit
+	runGit(t, repo, "commit", "-q", "--allow-empty", "-m", "empty")
+	third := runGit(t, repo, "rev-parse", "HEAD")
+	assert.NotEqual(second, third)
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("watchers not notified")
+	}
+	l, err = p.Items()
+	assert.NoError(err)
+	if assert.Len(l.Items, 2) {
+		assert.Equal("db2", mustValue(l.Items[0]))
+		assert.Equal(third, l.Items[0].Revision())
+	}
+
+	// the tag did not move
+	p = GitCustom(repo, GitOptions{Ref: "v1", Path: "myapp/db.yml"})
+	defer UnregisterProvider("git:" + repo + "@v1:myapp/db.yml")
+	l, err = p.Items()
+	assert.NoError(err)
+	if assert.Len(l.Items, 1) {
+		assert.Equal("db1", mustValue(l.Items[0]))
+		assert.Equal(first, l.Items[0].Revision())
+	}
+
+	p = GitCustom(repo, GitOptions{Ref: "unknown"})
+	defer UnregisterProvider("git:" + repo + "@unknown")
+	_, err = p.Items()
+	assert.Error(err)
+}
@@ -0,0 +1,255 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: configstore.proto
+
+package grpcstore
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Item is a configuration item: a key/value pair with a priority attached.
+type Item struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Key      string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value    string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Priority int64                  `protobuf:"varint,3,opt,name=priority,proto3" json:"priority,omitempty"`
+	// sensitive items hold secrets (credentials, ...)
+	Sensitive     bool `protobuf:"varint,4,opt,name=sensitive,proto3" json:"sensitive,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Item) Reset() {
+	*x = Item{}
+	mi := &file_configstore_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Item) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Item) ProtoMessage() {}
+
+func (x *Item) ProtoReflect() protoreflect.Message {
+	mi := &file_configstore_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Item.ProtoReflect.Descriptor instead.
+func (*Item) Descriptor() ([]byte, []int) {
+	return file_configstore_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Item) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Item) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Item) GetPriority() int64 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *Item) GetSensitive() bool {
+	if x != nil {
+		return x.Sensitive
+	}
+	return false
+}
+
+// ItemList is a list of items, in the same order as configstore.GetItemList.
+type ItemList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*Item                `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ItemList) Reset() {
+	*x = ItemList{}
+	mi := &file_configstore_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ItemList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ItemList) ProtoMessage() {}
+
+func (x *ItemList) ProtoReflect() protoreflect.Message {
+	mi := &file_configstore_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ItemList.ProtoReflect.Descriptor instead.
+func (*ItemList) Descriptor() ([]byte, []int) {
+	return file_configstore_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ItemList) GetItems() []*Item {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type ListRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name of a filter registered via configstore.RegisterFilter, empty for the full item list
+	Filter        string `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_configstore_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_configstore_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_configstore_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListRequest) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+var File_configstore_proto protoreflect.FileDescriptor
+
+const file_configstore_proto_rawDesc = "" +
+	"\n" +
+	"\x11configstore.proto\x12\x0econfigstore.v1\"h\n" +
+	"\x04Item\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x1a\n" +
+	"\bpriority\x18\x03 \x01(\x03R\bpriority\x12\x1c\n" +
+	"\tsensitive\x18\x04 \x01(\bR\tsensitive\"6\n" +
+	"\bItemList\x12*\n" +
+	"\x05items\x18\x01 \x03(\v2\x14.configstore.v1.ItemR\x05items\"%\n" +
+	"\vListRequest\x12\x16\n" +
+	"\x06filter\x18\x01 \x01(\tR\x06filter2\x8e\x01\n" +
+	"\vConfigStore\x12=\n" +
+	"\x04List\x12\x1b.configstore.v1.ListRequest\x1a\x18.configstore.v1.ItemList\x12@\n" +
+	"\x05Watch\x12\x1b.configstore.v1.ListRequest\x1a\x18.configstore.v1.ItemList0\x01B&Z$github.com/ovh/configstore/grpcstoreb\x06proto3"
+
+var (
+	file_configstore_proto_rawDescOnce sync.Once
+	file_configstore_proto_rawDescData []byte
+)
+
+func file_configstore_proto_rawDescGZIP() []byte {
+	file_configstore_proto_rawDescOnce.Do(func() {
+		file_configstore_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_configstore_proto_rawDesc), len(file_configstore_proto_rawDesc)))
+	})
+	return file_configstore_proto_rawDescData
+}
+
+var file_configstore_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_configstore_proto_goTypes = []any{
+	(*Item)(nil),        // 0: configstore.v1.Item
+	(*ItemList)(nil),    // 1: configstore.v1.ItemList
+	(*ListRequest)(nil), // 2: configstore.v1.ListRequest
+}
+var file_configstore_proto_depIdxs = []int32{
+	0, // 0: configstore.v1.ItemList.items:type_name -> configstore.v1.Item
+	2, // 1: configstore.v1.ConfigStore.List:input_type -> configstore.v1.ListRequest
+	2, // 2: configstore.v1.ConfigStore.Watch:input_type -> configstore.v1.ListRequest
+	1, // 3: configstore.v1.ConfigStore.List:output_type -> configstore.v1.ItemList
+	1, // 4: configstore.v1.ConfigStore.Watch:output_type -> configstore.v1.ItemList
+	3, // [3:5] is the sub-list for method output_type
+	1, // [1:3] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_configstore_proto_init() }
+func file_configstore_proto_init() {
+	if File_configstore_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_configstore_proto_rawDesc), len(file_configstore_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_configstore_proto_goTypes,
+		DependencyIndexes: file_configstore_proto_depIdxs,
+		MessageInfos:      file_configstore_proto_msgTypes,
+	}.Build()
+	File_configstore_proto = out.File
+	file_configstore_proto_goTypes = nil
+	file_configstore_proto_depIdxs = nil
+}
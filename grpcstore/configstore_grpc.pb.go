@@ -0,0 +1,171 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: configstore.proto
+
+package grpcstore
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ConfigStore_List_FullMethodName  = "/configstore.v1.ConfigStore/List"
+	ConfigStore_Watch_FullMethodName = "/configstore.v1.ConfigStore/Watch"
+)
+
+// ConfigStoreClient is the client API for ConfigStore service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ConfigStore exposes the configuration of a process.
+type ConfigStoreClient interface {
+	// List returns the current item list.
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ItemList, error)
+	// Watch streams the item list: once initially, then each time it changes.
+	Watch(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ItemList], error)
+}
+
+type configStoreClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConfigStoreClient(cc grpc.ClientConnInterface) ConfigStoreClient {
+	return &configStoreClient{cc}
+}
+
+func (c *configStoreClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ItemList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ItemList)
+	err := c.cc.Invoke(ctx, ConfigStore_List_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configStoreClient) Watch(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ItemList], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ConfigStore_ServiceDesc.Streams[0], ConfigStore_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListRequest, ItemList]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConfigStore_WatchClient = grpc.ServerStreamingClient[ItemList]
+
+// ConfigStoreServer is the server API for ConfigStore service.
+// All implementations must embed UnimplementedConfigStoreServer
+// for forward compatibility.
+//
+// ConfigStore exposes the configuration of a process.
+type ConfigStoreServer interface {
+	// List returns the current item list.
+	List(context.Context, *ListRequest) (*ItemList, error)
+	// Watch streams the item list: once initially, then each time it changes.
+	Watch(*ListRequest, grpc.ServerStreamingServer[ItemList]) error
+	mustEmbedUnimplementedConfigStoreServer()
+}
+
+// UnimplementedConfigStoreServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedConfigStoreServer struct{}
+
+func (UnimplementedConfigStoreServer) List(context.Context, *ListRequest) (*ItemList, error) {
+	return nil, status.Error(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedConfigStoreServer) Watch(*ListRequest, grpc.ServerStreamingServer[ItemList]) error {
+	return status.Error(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedConfigStoreServer) mustEmbedUnimplementedConfigStoreServer() {}
+func (UnimplementedConfigStoreServer) testEmbeddedByValue()                     {}
+
+// UnsafeConfigStoreServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConfigStoreServer will
+// result in compilation errors.
+type UnsafeConfigStoreServer interface {
+	mustEmbedUnimplementedConfigStoreServer()
+}
+
+func RegisterConfigStoreServer(s grpc.ServiceRegistrar, srv ConfigStoreServer) {
+	// If the following call panics, it indicates UnimplementedConfigStoreServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ConfigStore_ServiceDesc, srv)
+}
+
+func _ConfigStore_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigStoreServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConfigStore_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigStoreServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConfigStore_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConfigStoreServer).Watch(m, &grpc.GenericServerStream[ListRequest, ItemList]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConfigStore_WatchServer = grpc.ServerStreamingServer[ItemList]
+
+// ConfigStore_ServiceDesc is the grpc.ServiceDesc for ConfigStore service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ConfigStore_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "configstore.v1.ConfigStore",
+	HandlerType: (*ConfigStoreServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "List",
+			Handler:    _ConfigStore_List_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _ConfigStore_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "configstore.proto",
+}
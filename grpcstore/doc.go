@@ -0,0 +1,8 @@
+// Package grpcstore exposes a configstore over gRPC, and provides a configstore provider consuming it.
+//
+// The service is defined in configstore.proto. To regenerate the Go code:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative configstore.proto
+package grpcstore
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative configstore.proto
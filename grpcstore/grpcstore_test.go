@@ -0,0 +1,117 @@
+package grpcstore
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ovh/configstore"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestWatch(t *testing.T) {
+	assert := assert.New(t)
+
+	// server side: the items are served by an in-memory provider, the client mirrors them in the same process,
+	// so the filter must not match the mirrored items
+	inmem := configstore.InMemory("grpc-source").Add(
+		configstore.NewItem("source.key", "v1", 1),
+		configstore.NewSensitiveItem("source.password", "hunter2", 1),
+	)
+	configstore.RegisterFilter("grpc", configstore.Filter().SubTree("source."), "grpc test items")
+	configstore.RegisterFilter("grpc-broken", configstore.Filter().SubTree("source.").Transform(func(i *configstore.Item) (string, error) {
+		v, _ := i.Value()
+		if i.Key() == "key" {
+			return v, errors.New("broken")
+		}
+		return v, nil
+	}), "grpc test items, with an error")
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	RegisterConfigStoreServer(srv, NewServer(ServerOptions{}))
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(err)
+	defer conn.Close()
+
+	l, err := NewConfigStoreClient(conn).List(context.Background(), &ListRequest{Filter: "grpc"})
+	assert.NoError(err)
+	assert.Len(l.GetItems(), 2)
+	assert.Equal(map[string]string{"key": "v1", "password": configstore.RedactedValue}, values(l))
+
+	// items carrying an error are sent as is
+	l, err = NewConfigStoreClient(conn).List(context.Background(), &ListRequest{Filter: "grpc-broken"})
+	assert.NoError(err)
+	assert.Equal(map[string]string{"key": "v1", "password": configstore.RedactedValue}, values(l))
+
+	l, err = NewServer(ServerOptions{ExposeSensitive: true}).List(context.Background(), &ListRequest{Filter: "grpc"})
+	assert.NoError(err)
+	assert.Equal(map[string]string{"key": "v1", "password": "hunter2"}, values(l))
+
+	_, err = NewConfigStoreClient(conn).List(context.Background(), &ListRequest{Filter: "unknown"})
+	assert.Error(err)
+
+	// client side
+	w := configstore.Watch()
+	p := Provider("grpc-client", conn, ProviderOptions{Filter: "grpc"})
+	defer configstore.UnregisterProvider("grpc-client")
+
+	items, err := p.Items()
+	assert.NoError(err)
+	assert.Len(items.Items, 2)
+	for _, it := range items.Items {
+		assert.Equal(it.Key() == "password", it.Sensitive())
+	}
+
+	inmem.Add(configstore.NewItem("source.other", "v2", 1))
+	configstore.NotifyWatchers()
+
+	deadline := time.After(time.Second)
+	for len(items.Items) != 3 {
+		select {
+		case <-w:
+		case <-deadline:
+			t.Fatal("provider was not updated")
+		}
+		items, err = p.Items()
+		assert.NoError(err)
+	}
+}
+
+func TestSyncTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	// nothing serves the listener: the stream never gets established
+	lis := bufconn.Listen(1 << 20)
+	defer lis.Close()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(err)
+	defer conn.Close()
+
+	p := Provider("grpc-timeout", conn, ProviderOptions{SyncTimeout: 50 * time.Millisecond})
+	_, err = p.Items()
+	assert.EqualError(err, "grpc-timeout: initial list timed out")
+	assert.NoError(configstore.UnregisterProvider("grpc-timeout"))
+}
+
+func values(l *ItemList) map[string]string {
+	ret := map[string]string{}
+	for _, it := range l.GetItems() {
+		ret[it.GetKey()] = it.GetValue()
+	}
+	return ret
+}
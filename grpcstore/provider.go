@@ -0,0 +1,128 @@
+package grpcstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ovh/configstore"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// ProviderOptions customizes the provider, see Provider.
+type ProviderOptions struct {
+	// Filter is the name of a filter registered on the server side (default: no filter).
+	Filter string
+	// SyncTimeout caps the wait for the first list (default 30s).
+	SyncTimeout time.Duration
+}
+
+// Provider registers a configstore provider which subscribes to the Watch stream of a ConfigStore service
+// reachable through conn, optionally through a filter registered on the server side.
+// The in-memory copy is kept in sync with the stream, and watchers get notified on each update.
+// The first list is received synchronously, within opts.SyncTimeout: past that, the provider returns an error
+// until the first list comes in. If the stream breaks, it is re-established after a delay,
+// and the last received items are kept in the meantime.
+// The subscription stops when the provider is closed (see configstore.UnregisterProvider and configstore.Shutdown).
+func Provider(name string, conn grpc.ClientConnInterface, opts ProviderOptions) *configstore.InMemoryProvider {
+	if opts.SyncTimeout <= 0 {
+		opts.SyncTimeout = 30 * time.Second
+	}
+
+	inmem := configstore.InMemory(name)
+	client := NewConfigStoreClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-inmem.Done()
+		cancel()
+	}()
+
+	// protected by mut: the first list (or error) closes first, which releases Provider, unless it timed out already
+	var mut sync.Mutex
+	first := make(chan struct{})
+	received, timedOut := false, false
+	release := func() {
+		if !received {
+			received = true
+			close(first)
+		}
+	}
+
+	go func() {
+		defer func() {
+			mut.Lock()
+			release()
+			mut.Unlock()
+		}()
+		for {
+			err := subscribe(ctx, client, opts.Filter, func(l *ItemList) {
+				items := make([]configstore.Item, 0, len(l.GetItems()))
+				for _, it := range l.GetItems() {
+					if it.GetSensitive() {
+						items = append(items, configstore.NewSensitiveItem(it.GetKey(), it.GetValue(), it.GetPriority()))
+					} else {
+						items = append(items, configstore.NewItem(it.GetKey(), it.GetValue(), it.GetPriority()))
+					}
+				}
+				mut.Lock()
+				inmem.Set(items...)
+				notify := received || timedOut
+				release()
+				mut.Unlock()
+				if notify {
+					configstore.NotifyWatchers()
+				}
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			logrus.Errorf("configstore: provider '%s': %s", name, err)
+			mut.Lock()
+			if !received {
+				inmem.SetError(err)
+				release()
+			}
+			mut.Unlock()
+			select {
+			case <-time.After(5 * time.Second):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	t := time.NewTimer(opts.SyncTimeout)
+	defer t.Stop()
+	select {
+	case <-first:
+	case <-t.C:
+		mut.Lock()
+		if !received {
+			timedOut = true
+			err := fmt.Errorf("%s: initial list timed out", name)
+			logrus.Errorf("configstore: provider '%s': %s", name, err)
+			inmem.SetError(err)
+		}
+		mut.Unlock()
+	}
+
+	return inmem
+}
+
+// Receives the stream until it breaks, calling f for each list.
+func subscribe(ctx context.Context, client ConfigStoreClient, filter string, f func(*ItemList)) error {
+	stream, err := client.Watch(ctx, &ListRequest{Filter: filter})
+	if err != nil {
+		return err
+	}
+	for {
+		l, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		f(l)
+	}
+}
@@ -0,0 +1,91 @@
+package grpcstore
+
+import (
+	"context"
+
+	"github.com/ovh/configstore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ServerOptions customizes the gRPC service, see NewServer.
+type ServerOptions struct {
+	// ExposeSensitive sends the actual values of sensitive items. By default, they are replaced by configstore.RedactedValue.
+	ExposeSensitive bool
+}
+
+// Server implements the ConfigStore gRPC service, backed by the configstore providers.
+type Server struct {
+	UnimplementedConfigStoreServer
+	opts ServerOptions
+}
+
+// NewServer returns a ConfigStore service implementation. Register it via RegisterConfigStoreServer.
+func NewServer(opts ServerOptions) *Server {
+	return &Server{opts: opts}
+}
+
+// List returns the current item list, optionally through a filter registered via configstore.RegisterFilter.
+func (s *Server) List(ctx context.Context, req *ListRequest) (*ItemList, error) {
+	return s.list(req)
+}
+
+// Watch streams the item list initially, then each time it changes (see configstore.Watch).
+// The stream ends when the client goes away, or when configstore.Shutdown is called.
+func (s *Server) Watch(req *ListRequest, stream ConfigStore_WatchServer) error {
+	ch := configstore.Watch()
+	defer configstore.Unwatch(ch)
+
+	var last *ItemList
+	for {
+		l, err := s.list(req)
+		if err != nil {
+			return err
+		}
+		if last == nil || !proto.Equal(l, last) {
+			err = stream.Send(l)
+			if err != nil {
+				return err
+			}
+			last = l
+		}
+
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return nil
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (s *Server) list(req *ListRequest) (*ItemList, error) {
+	var filter *configstore.ItemFilter
+	if req.GetFilter() != "" {
+		f, ok := configstore.LookupFilter(req.GetFilter())
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "unknown filter: %s", req.GetFilter())
+		}
+		filter = f
+	}
+
+	items, err := configstore.GetItemList()
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	items = filter.Apply(items)
+
+	ret := &ItemList{Items: make([]*Item, 0, len(items.Items))}
+	for _, it := range items.Items {
+		// an item carrying an error (unmarshal, validation, ...) is sent with its raw value, like configstore.Handler does
+		v, _ := it.Value()
+		if !s.opts.ExposeSensitive && it.Sensitive() {
+			v = configstore.RedactedValue
+		}
+		ret.Items = append(ret.Items, &Item{Key: it.Key(), Value: v, Priority: it.Priority(), Sensitive: it.Sensitive()})
+	}
+	return ret, nil
+}
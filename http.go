@@ -0,0 +1,155 @@
+package configstore
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// HTTPHeaderEnvVarPrefix defines the prefix of the environment variables used to set custom headers on the requests of
+	// the HTTP provider: CONFIGURATION_HTTP_HEADER_X_API_KEY=foo sends the header "X-Api-Key: foo".
+	HTTPHeaderEnvVarPrefix = "CONFIGURATION_HTTP_HEADER_"
+	// HTTPCAFileEnvVar defines the environment variable used to set the CA bundle trusted by the HTTP provider.
+	HTTPCAFileEnvVar = "CONFIGURATION_HTTP_CA_FILE"
+	// HTTPCertFileEnvVar defines the environment variable used to set the client certificate of the HTTP provider.
+	HTTPCertFileEnvVar = "CONFIGURATION_HTTP_CERT_FILE"
+	// HTTPKeyFileEnvVar defines the environment variable used to set the client certificate key of the HTTP provider.
+	HTTPKeyFileEnvVar = "CONFIGURATION_HTTP_KEY_FILE"
+)
+
+// HTTPOptions customizes the HTTP provider, see HTTPCustom.
+type HTTPOptions struct {
+	// Interval between two polls (default 10s).
+	Interval time.Duration
+	// Headers sent with each request, e.g. for authentication.
+	Headers http.Header
+	// TLSConfig of the HTTP client. Ignored if Client is set.
+	TLSConfig *tls.Config
+	// Client used for the requests (default: a client with a 10s timeout).
+	Client *http.Client
+	// Unmarshal loads the response body (default: the same JSON/YAML item list format as File).
+	Unmarshal func([]byte) ([]Item, error)
+}
+
+// HTTP registers a configstore provider which fetches the URL given in parameter, expecting the same JSON/YAML
+// item list format as File. The URL is polled using conditional requests (ETag), and watchers get notified on change.
+// Headers and TLS client certificates are read from the environment, see HTTPHeaderEnvVarPrefix and HTTPCAFileEnvVar.
+func HTTP(url string) {
+	if url == "" {
+		return
+	}
+	opts, err := httpOptionsFromEnv()
+	if err != nil {
+		ErrorProvider(fmt.Sprintf("http:%s", url), err)
+		return
+	}
+	HTTPCustom(url, opts)
+}
+
+// HTTPCustom is similar to HTTP, with explicit options instead of the environment.
+func HTTPCustom(url string, opts HTTPOptions) *InMemoryProvider {
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: opts.TLSConfig},
+		}
+	}
+
+	etag := ""
+	var last []Item
+
+	fetch := func(ctx context.Context) ([]Item, error) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+		for k, v := range opts.Headers {
+			req.Header[k] = v
+		}
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		switch resp.StatusCode {
+		case http.StatusNotModified:
+			return last, nil
+		case http.StatusOK:
+		default:
+			return nil, fmt.Errorf("unexpected HTTP status: %s", resp.Status)
+		}
+
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		items, err := unmarshalItems(b, opts.Unmarshal)
+		if err != nil {
+			return nil, err
+		}
+		etag = resp.Header.Get("ETag")
+		last = items
+		return items, nil
+	}
+
+	return Polling(fmt.Sprintf("http:%s", url), opts.Interval, fetch)
+}
+
+func httpOptionsFromEnv() (HTTPOptions, error) {
+	opts := HTTPOptions{Headers: http.Header{}}
+
+	for _, e := range os.Environ() {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], HTTPHeaderEnvVarPrefix) {
+			continue
+		}
+		name := strings.Replace(strings.TrimPrefix(parts[0], HTTPHeaderEnvVarPrefix), "_", "-", -1)
+		opts.Headers.Set(name, parts[1])
+	}
+
+	caFile := os.Getenv(HTTPCAFileEnvVar)
+	certFile := os.Getenv(HTTPCertFileEnvVar)
+	keyFile := os.Getenv(HTTPKeyFileEnvVar)
+	if caFile == "" && certFile == "" {
+		return opts, nil
+	}
+
+	opts.TLSConfig = &tls.Config{}
+	if caFile != "" {
+		b, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return opts, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return opts, errors.New("no certificate found in " + caFile)
+		}
+		opts.TLSConfig.RootCAs = pool
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return opts, err
+		}
+		opts.TLSConfig.Certificates = []tls.Certificate{cert}
+	}
+	return opts, nil
+}
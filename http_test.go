@@ -0,0 +1,72 @@
+package configstore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTP(t *testing.T) {
+	assert := assert.New(t)
+
+	var mut sync.Mutex
+	body := `[{"key": "http", "value": "v1", "priority": 1}]`
+	etag := `"1"`
+	notModified := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		defer mut.Unlock()
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	os.Setenv(HTTPHeaderEnvVarPrefix+"X_API_KEY", "secret")
+	defer os.Unsetenv(HTTPHeaderEnvVarPrefix + "X_API_KEY")
+	os.Setenv(ConfigEnvVar, "http:"+srv.URL)
+	InitFromEnvironment()
+	assert.Equal("v1", must(GetItemValue("http")))
+	assert.NoError(UnregisterProvider("http:" + srv.URL))
+
+	w := Watch()
+	HTTPCustom(srv.URL+"/custom", HTTPOptions{Interval: time.Millisecond, Headers: http.Header{"X-Api-Key": {"secret"}}})
+	defer UnregisterProvider("http:" + srv.URL + "/custom")
+	assert.Equal("v1", must(GetItemValue("http")))
+
+	mut.Lock()
+	body = "- key: http\n  value: v2\n"
+	etag = `"2"`
+	mut.Unlock()
+
+	select {
+	case <-w:
+	case <-time.After(time.Second):
+		t.Fatal("watchers were not notified")
+	}
+	assert.Equal("v2", must(GetItemValue("http")))
+
+	time.Sleep(20 * time.Millisecond)
+	mut.Lock()
+	assert.True(notModified > 0)
+	mut.Unlock()
+
+	unauthorized := HTTPCustom(srv.URL+"/unauthorized", HTTPOptions{Interval: time.Hour})
+	defer UnregisterProvider("http:" + srv.URL + "/unauthorized")
+	_, err := unauthorized.Items()
+	assert.EqualError(err, "unexpected HTTP status: 401 Unauthorized")
+}
@@ -2,6 +2,7 @@ package configstore
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"strconv"
 	"time"
 
@@ -16,13 +17,20 @@ type Item struct {
 	priority     int64
 	unmarshaled  interface{}
 	unmarshalErr error
+	provider     string
+	layer        Layer
+	stale        bool
+	sensitive    bool
+	revision     string
 }
 
 // Strictly used for unmarshaling, bypassing the fact that a Item properties are private
 type jsonItem struct {
-	Key      string `json:"key"`
-	Value    string `json:"value"`
-	Priority int64  `json:"priority"`
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+	Priority  int64  `json:"priority"`
+	Sensitive bool   `json:"sensitive,omitempty"`
+	Revision  string `json:"revision,omitempty"`
 }
 
 // NewItem creates a item object from key / value / priority values.
@@ -31,6 +39,17 @@ func NewItem(key, value string, priority int64) Item {
 	return Item{key: key, value: value, priority: priority}
 }
 
+// MarshalJSON respects json.Marshaler, using the same envelope as UnmarshalJSON.
+func (s Item) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonItem{Key: s.key, Value: s.value, Priority: s.priority, Sensitive: s.sensitive, Revision: s.revision})
+}
+
+// NewSensitiveItem is similar to NewItem, but flags the item as sensitive (secrets, credentials, ...).
+// Sensitive item values are redacted when exposed, see Handler.
+func NewSensitiveItem(key, value string, priority int64) Item {
+	return Item{key: key, value: value, priority: priority, sensitive: true}
+}
+
 // UnmarshalJSON respects json.Unmarshaler
 func (s *Item) UnmarshalJSON(b []byte) error {
 	j := &jsonItem{}
@@ -41,6 +60,8 @@ func (s *Item) UnmarshalJSON(b []byte) error {
 	s.key = j.Key
 	s.value = j.Value
 	s.priority = j.Priority
+	s.sensitive = j.Sensitive
+	s.revision = j.Revision
 	return nil
 }
 
@@ -114,7 +135,43 @@ func (s Item) Priority() int64 {
 	return s.priority
 }
 
-// Tries to unmarshal (from JSON or YAML) the item value into i.
+// Layer returns the layer of the provider which produced the item (see RegisterProviderLayer).
+// Items are ordered by layer first, then by priority.
+func (s Item) Layer() Layer {
+	return s.layer
+}
+
+// Stale returns true if the item is a cached copy served because its provider failed (see WithLastKnownGood).
+func (s Item) Stale() bool {
+	return s.stale
+}
+
+// Sensitive returns true if the item holds a secret, see NewSensitiveItem and ItemFilter.Sensitive.
+func (s Item) Sensitive() bool {
+	return s.sensitive
+}
+
+// Revision returns the revision of the source the item was read from, if the provider tracks one
+// (e.g. the commit hash for the Git provider).
+func (s Item) Revision() string {
+	return s.revision
+}
+
+// Returns true if s should be considered before o: higher layer, or same layer and higher priority.
+func (s *Item) outranks(o *Item) bool {
+	if s.layer != o.layer {
+		return s.layer > o.layer
+	}
+	return s.priority > o.priority
+}
+
+// Provider returns the name of the provider which produced the item (see RegisterProvider).
+// It is only set on items retrieved via GetItemList.
+func (s Item) Provider() string {
+	return s.provider
+}
+
+// Tries to unmarshal (from JSON or YAML) the item value into i, then validates it (see Validator).
 // The result and error are stored within the item object, to be handled later.
 func (s *Item) storeUnmarshal(i interface{}) {
 	if s.unmarshalErr != nil {
@@ -126,6 +183,7 @@ func (s *Item) storeUnmarshal(i interface{}) {
 		return
 	}
 	s.unmarshaled = i
+	s.unmarshalErr = validate(s.key, i)
 }
 
 // Unmarshaled returns the unmarshaled object produced by ItemFilter.Unmarshal, along with any error
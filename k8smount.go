@@ -0,0 +1,115 @@
+package configstore
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// k8sDataDir is the symlink maintained by the Kubernetes atomic writer, pointing to the current timestamped
+// directory (e.g. "..2024_01_02_15_04_05.123456789") which holds the actual files.
+const k8sDataDir = "..data"
+
+// K8sMountOptions customizes the Kubernetes mount provider, see K8sMountCustom.
+type K8sMountOptions struct {
+	// Interval between two checks of the "..data" symlink (default 10s).
+	Interval time.Duration
+	// Sensitive flags all the items as sensitive, e.g. for a Secret volume (see Item.Sensitive).
+	Sensitive bool
+	// DecodeKey returns the item key and priority of a ConfigMap/Secret key. Keys of nested files (projected
+	// with a path) are slash separated. Default: the key is kept as is, and follows the same capitalization
+	// convention as FileTree for the priority.
+	DecodeKey func(string) (string, int64)
+}
+
+// K8sMount registers a configstore provider which reads a Kubernetes ConfigMap or Secret volume mounted at the
+// directory given in parameter. Each key of the ConfigMap/Secret becomes an item, holding the plain file content.
+// The atomic writer layout is understood: the files are read from the directory targeted by the "..data" symlink,
+// entries starting with a dot are ignored, and the items are reloaded when "..data" is retargeted (watchers get notified).
+// Directories which do not follow this layout are read as is, and reloaded when their content changes.
+func K8sMount(dirname string) {
+	if dirname == "" {
+		return
+	}
+	K8sMountCustom(dirname, K8sMountOptions{})
+}
+
+// K8sMountCustom is similar to K8sMount, with explicit options.
+func K8sMountCustom(dirname string, opts K8sMountOptions) *InMemoryProvider {
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+	if opts.DecodeKey == nil {
+		opts.DecodeKey = decodeK8sKey
+	}
+
+	target := ""
+	var last []Item
+
+	fetch := func(ctx context.Context) ([]Item, error) {
+		root := dirname
+		t, err := os.Readlink(filepath.Join(dirname, k8sDataDir))
+		if err == nil {
+			if t == target {
+				return last, nil
+			}
+			root = filepath.Join(dirname, k8sDataDir)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		items, err := readK8sDir(nil, root, "", opts)
+		if err != nil {
+			return nil, err
+		}
+		target = t
+		last = items
+		return items, nil
+	}
+
+	return Polling(fmt.Sprintf("k8smount:%s", dirname), opts.Interval, fetch)
+}
+
+// Reads the files under dir recursively, ignoring dot-prefixed entries.
+func readK8sDir(items []Item, dir, prefix string, opts K8sMountOptions) ([]Item, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return items, err
+	}
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		filename := filepath.Join(dir, f.Name())
+		// the keys are symlinks to "..data/<key>": follow them
+		finfo, err := os.Stat(filename)
+		if err != nil {
+			return items, err
+		}
+		if finfo.IsDir() {
+			items, err = readK8sDir(items, filename, prefix+f.Name()+"/", opts)
+			if err != nil {
+				return items, err
+			}
+			continue
+		}
+		content, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return items, err
+		}
+		key, priority := opts.DecodeKey(prefix + f.Name())
+		it := NewItem(key, string(content), priority)
+		it.sensitive = opts.Sensitive
+		items = append(items, it)
+	}
+	return items, nil
+}
+
+// Default key decoding: same priority convention as FileTree, capitalized = higher priority.
+func decodeK8sKey(key string) (string, int64) {
+	return key, filePriority(filepath.Base(key))
+}
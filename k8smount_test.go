@@ -0,0 +1,87 @@
+package configstore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeK8sMount reproduces the Kubernetes atomic writer: files are written to a new timestamped directory,
+// then "..data" is atomically retargeted to it.
+func writeK8sMount(t *testing.T, dir, ts string, files map[string]string) {
+	tsDir := filepath.Join(dir, ts)
+	for name, content := range files {
+		p := filepath.Join(tsDir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tmp := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink(ts, tmp); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, k8sDataDir)); err != nil {
+		t.Fatal(err)
+	}
+	for name := range files {
+		top := strings.SplitN(name, "/", 2)[0]
+		os.Symlink(filepath.Join(k8sDataDir, top), filepath.Join(dir, top))
+	}
+}
+
+func TestK8sMount(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "k8smount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeK8sMount(t, dir, "..2024_01_02_15_04_05.000000001", map[string]string{
+		"k8s.host":   "db1",
+		"K8s.port":   "5432",
+		"nested/key": "nested",
+	})
+
+	p := K8sMountCustom(dir, K8sMountOptions{Interval: 50 * time.Millisecond, Sensitive: true})
+	defer UnregisterProvider("k8smount:" + dir)
+
+	l, err := p.Items()
+	assert.NoError(err)
+	items := (&ItemList{Items: l.Items}).index()
+	assert.Len(l.Items, 3)
+	i, err := items.GetItem("k8s.host")
+	assert.NoError(err)
+	assert.Equal("db1", mustValue(i))
+	assert.Equal(int64(5), i.Priority())
+	assert.True(i.Sensitive())
+	i, err = items.GetItem("K8s.port")
+	assert.NoError(err)
+	assert.Equal(int64(10), i.Priority())
+	assert.Equal("nested", mustValue(must(items.GetItem("nested/key")).(Item)))
+
+	ch := Watch()
+	defer Unwatch(ch)
+
+	writeK8sMount(t, dir, "..2024_01_02_15_05_05.000000002", map[string]string{
+		"k8s.host": "db2",
+	})
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("watchers not notified")
+	}
+	l, err = p.Items()
+	assert.NoError(err)
+	assert.Len(l.Items, 1)
+	assert.Equal("db2", mustValue(l.Items[0]))
+}
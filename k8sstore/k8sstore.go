@@ -0,0 +1,233 @@
+// Package k8sstore provides a configstore provider reading its items from Kubernetes ConfigMaps or Secrets,
+// through the API. Changes are seen as soon as the API server reports them, without the delay of volume mounts.
+//
+// Importing the package registers the "k8sconfigmap" and "k8ssecret" provider factories, see InitFromEnvironment.
+// Their parameter is "<namespace>/<label selector>", the namespace defaulting to the one of the pod:
+//
+//	CONFIGURATION_FROM=k8sconfigmap:/app=myapp,k8ssecret:default/app=myapp
+package k8sstore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ovh/configstore"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// PriorityAnnotation is the annotation of a ConfigMap or Secret which sets the priority of all its items (default 0).
+	PriorityAnnotation = "configstore/priority"
+
+	// file holding the namespace of the pod, see Options.Namespace
+	namespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+func init() {
+	configstore.RegisterProviderFactory("k8sconfigmap", func(param string) { factory("k8sconfigmap", param, false) })
+	configstore.RegisterProviderFactory("k8ssecret", func(param string) { factory("k8ssecret", param, true) })
+}
+
+func factory(kind, param string, secrets bool) {
+	name := fmt.Sprintf("%s:%s", kind, param)
+	parts := strings.SplitN(param, "/", 2)
+	opts := Options{Namespace: parts[0], Secrets: secrets}
+	if len(parts) == 2 {
+		opts.LabelSelector = parts[1]
+	}
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		configstore.ErrorProvider(name, err)
+		return
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		configstore.ErrorProvider(name, err)
+		return
+	}
+	Provider(name, client, opts)
+}
+
+// Options customizes the provider, see Provider.
+type Options struct {
+	// Namespace of the ConfigMaps/Secrets (default: the namespace of the pod).
+	Namespace string
+	// LabelSelector restricts the ConfigMaps/Secrets, e.g. "app=myapp" (default: all of the namespace).
+	LabelSelector string
+	// Secrets reads Secrets instead of ConfigMaps. Their items are flagged as sensitive (see configstore.Item.Sensitive).
+	Secrets bool
+	// ResyncPeriod of the informer (default: no resync).
+	ResyncPeriod time.Duration
+	// SyncTimeout caps the wait for the initial listing (default 30s).
+	SyncTimeout time.Duration
+}
+
+// Provider registers a configstore provider which reads the ConfigMaps (or Secrets) matching the options.
+// Each data entry becomes an item keyed by the entry key; its priority is set by the PriorityAnnotation of the
+// ConfigMap/Secret. An informer keeps the in-memory copy up to date, and watchers get notified on each update.
+// The initial listing happens synchronously. The informer stops when the provider is closed (see configstore.UnregisterProvider).
+func Provider(name string, client kubernetes.Interface, opts Options) *configstore.InMemoryProvider {
+	if opts.Namespace == "" {
+		b, err := ioutil.ReadFile(namespaceFile)
+		if err == nil {
+			opts.Namespace = strings.TrimSpace(string(b))
+		}
+	}
+	if opts.SyncTimeout <= 0 {
+		opts.SyncTimeout = 30 * time.Second
+	}
+
+	inmem := configstore.InMemory(name)
+	factory := informers.NewSharedInformerFactoryWithOptions(client, opts.ResyncPeriod,
+		informers.WithNamespace(opts.Namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) { o.LabelSelector = opts.LabelSelector }),
+	)
+
+	var informer cache.SharedIndexInformer
+	var list func() ([]configstore.Item, error)
+	if opts.Secrets {
+		i := factory.Core().V1().Secrets()
+		informer = i.Informer()
+		list = func() ([]configstore.Item, error) {
+			secrets, err := i.Lister().List(labels.Everything())
+			if err != nil {
+				return nil, err
+			}
+			return secretItems(name, secrets), nil
+		}
+	} else {
+		i := factory.Core().V1().ConfigMaps()
+		informer = i.Informer()
+		list = func() ([]configstore.Item, error) {
+			cms, err := i.Lister().List(labels.Everything())
+			if err != nil {
+				return nil, err
+			}
+			return configMapItems(name, cms), nil
+		}
+	}
+
+	synced := int32(0)
+	publish := func() {
+		items, err := list()
+		if err != nil {
+			logrus.Errorf("configstore: provider '%s': %s", name, err)
+			return
+		}
+		inmem.Set(items...)
+	}
+	onEvent := func() {
+		// events of the initial listing are published at once, see below
+		if atomic.LoadInt32(&synced) == 1 {
+			publish()
+			configstore.NotifyWatchers()
+		}
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { onEvent() },
+		UpdateFunc: func(interface{}, interface{}) { onEvent() },
+		DeleteFunc: func(interface{}) { onEvent() },
+	})
+
+	factory.Start(inmem.Done())
+
+	timeout := make(chan struct{})
+	t := time.AfterFunc(opts.SyncTimeout, func() { close(timeout) })
+	defer t.Stop()
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-timeout:
+		case <-inmem.Done():
+		}
+		close(stop)
+	}()
+	if !cache.WaitForCacheSync(stop, informer.HasSynced) {
+		err := fmt.Errorf("%s: initial listing timed out", name)
+		logrus.Errorf("configstore: provider '%s': %s", name, err)
+		inmem.SetError(err)
+		// publish once the informer eventually syncs
+		go func() {
+			if cache.WaitForCacheSync(inmem.Done(), informer.HasSynced) {
+				atomic.StoreInt32(&synced, 1)
+				publish()
+				configstore.NotifyWatchers()
+			}
+		}()
+		return inmem
+	}
+	atomic.StoreInt32(&synced, 1)
+	publish()
+	return inmem
+}
+
+func configMapItems(name string, cms []*corev1.ConfigMap) []configstore.Item {
+	sort.Slice(cms, func(i, j int) bool { return cms[i].Name < cms[j].Name })
+	items := []configstore.Item{}
+	for _, cm := range cms {
+		data := map[string]string{}
+		for k, v := range cm.Data {
+			data[k] = v
+		}
+		for k, v := range cm.BinaryData {
+			data[k] = string(v)
+		}
+		items = appendItems(items, data, objectPriority(name, cm.ObjectMeta), false)
+	}
+	return items
+}
+
+func secretItems(name string, secrets []*corev1.Secret) []configstore.Item {
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+	items := []configstore.Item{}
+	for _, s := range secrets {
+		data := map[string]string{}
+		for k, v := range s.Data {
+			data[k] = string(v)
+		}
+		items = appendItems(items, data, objectPriority(name, s.ObjectMeta), true)
+	}
+	return items
+}
+
+// Appends the data entries as items, sorted by key.
+func appendItems(items []configstore.Item, data map[string]string, priority int64, sensitive bool) []configstore.Item {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if sensitive {
+			items = append(items, configstore.NewSensitiveItem(k, data[k], priority))
+		} else {
+			items = append(items, configstore.NewItem(k, data[k], priority))
+		}
+	}
+	return items
+}
+
+func objectPriority(name string, meta metav1.ObjectMeta) int64 {
+	s, ok := meta.Annotations[PriorityAnnotation]
+	if !ok {
+		return 0
+	}
+	p, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		logrus.Warnf("configstore: provider '%s': %s/%s: invalid %s annotation: %s", name, meta.Namespace, meta.Name, PriorityAnnotation, s)
+		return 0
+	}
+	return p
+}
@@ -0,0 +1,80 @@
+package k8sstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ovh/configstore"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestProvider(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	client := fake.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "base", Namespace: "ns", Labels: map[string]string{"app": "myapp"}},
+			Data:       map[string]string{"db.host": "db1", "db.port": "5432"},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "ns", Labels: map[string]string{"app": "other"}},
+			Data:       map[string]string{"ignored": "true"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "ns", Labels: map[string]string{"app": "myapp"}},
+			Data:       map[string][]byte{"db.password": []byte("hunter2")},
+		},
+	)
+
+	cms := Provider("k8sconfigmap:test", client, Options{Namespace: "ns", LabelSelector: "app=myapp"})
+	defer configstore.UnregisterProvider("k8sconfigmap:test")
+	secrets := Provider("k8ssecret:test", client, Options{Namespace: "ns", LabelSelector: "app=myapp", Secrets: true})
+	defer configstore.UnregisterProvider("k8ssecret:test")
+
+	l, err := cms.Items()
+	assert.NoError(err)
+	if assert.Len(l.Items, 2) {
+		assert.Equal("db.host", l.Items[0].Key())
+		assert.Equal(int64(0), l.Items[0].Priority())
+		assert.False(l.Items[0].Sensitive())
+	}
+	l, err = secrets.Items()
+	assert.NoError(err)
+	if assert.Len(l.Items, 1) {
+		assert.Equal("db.password", l.Items[0].Key())
+		assert.True(l.Items[0].Sensitive())
+	}
+
+	ch := configstore.Watch()
+	defer configstore.Unwatch(ch)
+
+	_, err = client.CoreV1().ConfigMaps("ns").Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "override",
+			Namespace:   "ns",
+			Labels:      map[string]string{"app": "myapp"},
+			Annotations: map[string]string{PriorityAnnotation: "10"},
+		},
+		Data: map[string]string{"db.host": "db2"},
+	}, metav1.CreateOptions{})
+	assert.NoError(err)
+
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchers not notified")
+	}
+	l, err = cms.Items()
+	assert.NoError(err)
+	assert.Len(l.Items, 3)
+	i, err := configstore.Filter().Squash().GetItem("db.host")
+	assert.NoError(err)
+	v, _ := i.Value()
+	assert.Equal("db2", v)
+	assert.Equal(int64(10), i.Priority())
+}
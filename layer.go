@@ -0,0 +1,38 @@
+package configstore
+
+// Layer ranks providers: items are ordered by the layer of their provider first, then by their own priority.
+// This makes the precedence between providers explicit, whatever raw priorities they pick for their items.
+type Layer int64
+
+// Suggested layers, from the lowest to the highest precedence.
+// Providers registered via RegisterProvider are in LayerDefaults, unless registered by InitFromEnvironment.
+const (
+	LayerDefaults Layer = 0
+	LayerFiles    Layer = 1000
+	LayerEnv      Layer = 2000
+	LayerFlags    Layer = 3000
+)
+
+var (
+	providerLayers = map[string]Layer{}
+	// layer assigned by RegisterProvider, protected by pMut
+	defaultLayer = LayerDefaults
+)
+
+// RegisterProviderLayer registers a provider in the given layer.
+// Its items will override the items of providers registered in lower layers, regardless of priority.
+func RegisterProviderLayer(name string, layer Layer, f Provider) {
+	pMut.Lock()
+	replaced := registerProvider(name, layer, f)
+	pMut.Unlock()
+	closeReplaced(name, replaced)
+}
+
+// Sets the layer assigned by RegisterProvider, returns the previous one.
+func setDefaultLayer(layer Layer) Layer {
+	pMut.Lock()
+	defer pMut.Unlock()
+	prev := defaultLayer
+	defaultLayer = layer
+	return prev
+}
@@ -0,0 +1,105 @@
+package configstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// protected by pMut
+var closers = map[string]io.Closer{}
+
+// closed by the first call to Shutdown, to interrupt the waits in progress (see WithRetry)
+var (
+	shutdownCh   = make(chan struct{})
+	shutdownOnce sync.Once
+)
+
+// RegisterProviderCloser attaches a lifecycle to a registered provider: c gets closed when the provider
+// is unregistered (see UnregisterProvider) or when the library shuts down (see Shutdown).
+// Providers running background goroutines (refresh, watch, ...) should use it to release them.
+func RegisterProviderCloser(name string, c io.Closer) {
+	pMut.Lock()
+	defer pMut.Unlock()
+	closers[name] = c
+}
+
+// Closes the closer of an overridden provider, so that its background goroutines stop.
+func closeReplaced(name string, c io.Closer) {
+	if c == nil {
+		return
+	}
+	err := c.Close()
+	if err != nil {
+		logrus.Warnf("configstore: closing overridden provider '%s': %s", name, err)
+	}
+}
+
+// UnregisterProvider closes a provider (if it has a closer, see RegisterProviderCloser) and removes it.
+// Watchers get notified, since the configuration changed.
+func UnregisterProvider(name string) error {
+	pMut.Lock()
+	_, ok := providers[name]
+	c := closers[name]
+	delete(providers, name)
+	delete(providerLayers, name)
+	delete(providerSeq, name)
+	delete(closers, name)
+	delete(optionalProviders, name)
+	pMut.Unlock()
+
+	if !ok {
+		return ErrProvider{Provider: name, Op: "unregister", Err: errors.New("provider not found")}
+	}
+
+	var err error
+	if c != nil {
+		err = c.Close()
+	}
+	NotifyWatchers()
+	return err
+}
+
+// Shutdown tears down all background activity: every provider closer is closed (see RegisterProviderCloser),
+// and every watch channel is closed, which ends the loops ranging over them (see Watch). Retries waiting for their
+// next attempt (see WithRetry) give up first, so that they release the providers, and later ones do not wait anymore.
+// The providers stay registered, so that the configuration can still be read during a graceful shutdown.
+// It returns the first error returned by a closer, or the context error if it expires before all closers return.
+func Shutdown(ctx context.Context) error {
+	// interrupt the retries in progress first: they may be holding pMut
+	shutdownOnce.Do(func() { close(shutdownCh) })
+
+	pMut.Lock()
+	toClose := closers
+	closers = map[string]io.Closer{}
+	pMut.Unlock()
+
+	errCh := make(chan error, 1)
+	go func() {
+		var firstErr error
+		for n, c := range toClose {
+			err := c.Close()
+			if err != nil && firstErr == nil {
+				firstErr = ErrProvider{Provider: n, Op: "close", Err: err}
+			}
+		}
+		errCh <- firstErr
+	}()
+
+	watchersMut.Lock()
+	for _, ch := range watchers {
+		close(ch)
+	}
+	watchers = nil
+	watchersMut.Unlock()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
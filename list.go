@@ -1,7 +1,6 @@
 package configstore
 
 import (
-	"fmt"
 	"sort"
 	"time"
 )
@@ -10,10 +9,13 @@ import (
 type ItemList struct {
 	Items   []Item
 	indexed map[string][]Item
+	errors  ErrMulti
 }
 
 // GetItemList retrieves the full item list, merging the results from all providers.
 // It does NOT cache, it's the responsability of the providers to keep an in-ram representation if desired.
+// Items using a deprecated key are also made available under their new key, see Alias.
+// The failure of an optional provider does not fail the whole list, see SetProviderOptional and ItemList.Errors.
 func GetItemList() (*ItemList, error) {
 
 	pMut.Lock()
@@ -24,9 +26,18 @@ func GetItemList() (*ItemList, error) {
 	for n, p := range providers {
 		l, err := p()
 		if err != nil {
-			return nil, ErrProvider(fmt.Sprintf("configstore: provider '%s': %s", n, err))
+			perr := ErrProvider{Provider: n, Err: err}
+			if !allowPartialFailure && !optionalProviders[n] {
+				return nil, perr
+			}
+			ret.errors = append(ret.errors, perr)
+			continue
+		}
+		for _, it := range resolveAliases(n, l.Items) {
+			it.provider = n
+			it.layer = providerLayers[n]
+			ret.Items = append(ret.Items, it)
 		}
-		ret.Items = append(ret.Items, l.Items...)
 	}
 	return ret.index(), nil
 }
@@ -95,6 +106,15 @@ func GetItemValueDuration(key string) (time.Duration, error) {
 	return i.ValueDuration()
 }
 
+// Errors returns the errors of the optional providers which failed while building the list, see SetProviderOptional.
+// It returns nil if all the providers succeeded.
+func (s *ItemList) Errors() error {
+	if s == nil || len(s.errors) == 0 {
+		return nil
+	}
+	return s.errors
+}
+
 // Keys returns a list of the different keys present in the item list.
 func (s *ItemList) Keys() []string {
 	if s == nil {
@@ -114,19 +134,19 @@ func (s *ItemList) Keys() []string {
 func (s *ItemList) GetItem(key string) (Item, error) {
 
 	if s == nil {
-		return Item{}, ErrUninitializedItemList(fmt.Sprintf("configstore: get '%s': non-initialized item list", key))
+		return Item{}, ErrUninitializedItemList{Key: key}
 	}
 
 	l := (&ItemFilter{}).Slice(key).Apply(s)
 
 	switch len(l.Items) {
 	case 0:
-		return Item{}, ErrItemNotFound(fmt.Sprintf("configstore: get '%s': no item found", key))
+		return Item{}, ErrItemNotFound{Key: key}
 	case 1:
 		return l.Items[0], nil
 
 	}
-	return Item{}, ErrAmbiguousItem(fmt.Sprintf("configstore: get '%s': ambiguous, %d items share that key", key, len(l.Items)))
+	return Item{}, ErrAmbiguousItem{Key: key, Count: len(l.Items)}
 }
 
 // GetItemValue returns a single item value, by key.
@@ -200,7 +220,7 @@ func (s *ItemList) Len() int {
 func (s *ItemList) Less(i, j int) bool {
 	s1 := s.Items[i]
 	s2 := s.Items[j]
-	return s1.priority > s2.priority
+	return s1.outranks(&s2)
 }
 
 // Implements sort.Interface
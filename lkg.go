@@ -0,0 +1,152 @@
+package configstore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LastKnownGoodProvider wraps a provider, and keeps serving its last successful result when it fails.
+// Items served from the cache are flagged as stale (see item.Stale()).
+type LastKnownGoodProvider struct {
+	provider    Provider
+	mut         sync.Mutex
+	last        []Item
+	hasLast     bool
+	lastSuccess time.Time
+	stale       bool
+	err         error
+	filename    string
+	// hash of the items last written to filename
+	persisted string
+}
+
+// WithLastKnownGood wraps the provider p: when p fails, the items of its last successful call are returned instead,
+// flagged as stale. The error is only returned if p never succeeded.
+// Register the result via RegisterProvider(name, lkg.Items).
+func WithLastKnownGood(p Provider) *LastKnownGoodProvider {
+	return &LastKnownGoodProvider{provider: p}
+}
+
+// Persist saves the cache to filename after each successful call changing the items, so that it survives a restart.
+// If the file already exists, its content is loaded as the initial (stale) cache.
+// The file uses the same format as the File provider.
+func (lkg *LastKnownGoodProvider) Persist(filename string) *LastKnownGoodProvider {
+	lkg.mut.Lock()
+	defer lkg.mut.Unlock()
+	lkg.filename = filename
+	if lkg.hasLast {
+		return lkg
+	}
+	finfo, err := os.Stat(filename)
+	if err != nil {
+		return lkg
+	}
+	items, err := readFile(filename, nil)
+	if err != nil {
+		logrus.Warnf("configstore: last known good cache '%s': %s", filename, err)
+		return lkg
+	}
+	lkg.last = items
+	lkg.hasLast = true
+	lkg.persisted = hashItems(items)
+	lkg.lastSuccess = finfo.ModTime()
+	lkg.stale = true
+	return lkg
+}
+
+// Items calls the wrapped provider, falling back on the cache if it fails. This is the function that gets called by configstore.
+func (lkg *LastKnownGoodProvider) Items() (ItemList, error) {
+	l, err := lkg.provider()
+
+	lkg.mut.Lock()
+	defer lkg.mut.Unlock()
+
+	if err == nil {
+		lkg.last = append([]Item{}, l.Items...)
+		lkg.hasLast = true
+		lkg.lastSuccess = time.Now()
+		lkg.stale = false
+		lkg.err = nil
+		if lkg.filename != "" {
+			h := hashItems(lkg.last)
+			if h != lkg.persisted {
+				perr := writeItemsFile(lkg.filename, lkg.last)
+				if perr != nil {
+					logrus.Warnf("configstore: last known good cache '%s': %s", lkg.filename, perr)
+				} else {
+					lkg.persisted = h
+				}
+			}
+		}
+		return l, nil
+	}
+
+	lkg.err = err
+	if !lkg.hasLast {
+		return ItemList{}, err
+	}
+	if !lkg.stale {
+		logrus.Warnf("configstore: provider failed, serving last known good configuration: %s", err)
+	}
+	lkg.stale = true
+	ret := ItemList{Items: make([]Item, len(lkg.last))}
+	for i, it := range lkg.last {
+		it.stale = true
+		ret.Items[i] = it
+	}
+	return ret, nil
+}
+
+// Stale returns true if the items currently served come from the cache.
+func (lkg *LastKnownGoodProvider) Stale() bool {
+	lkg.mut.Lock()
+	defer lkg.mut.Unlock()
+	return lkg.stale
+}
+
+// Age returns the time elapsed since the last successful call of the wrapped provider.
+// It returns 0 if the provider never succeeded.
+func (lkg *LastKnownGoodProvider) Age() time.Duration {
+	lkg.mut.Lock()
+	defer lkg.mut.Unlock()
+	if !lkg.hasLast {
+		return 0
+	}
+	return time.Since(lkg.lastSuccess)
+}
+
+// Err returns the error of the last call of the wrapped provider, nil if it succeeded.
+func (lkg *LastKnownGoodProvider) Err() error {
+	lkg.mut.Lock()
+	defer lkg.mut.Unlock()
+	return lkg.err
+}
+
+// Writes the items to filename atomically, in the File provider format.
+func writeItemsFile(filename string, items []Item) error {
+	b, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
@@ -0,0 +1,131 @@
+package configstore
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/ghodss/yaml"
+)
+
+// ListStrategy defines how Merge combines lists found at the same place in several item values.
+type ListStrategy int
+
+const (
+	// ListReplace keeps the list of the highest priority item.
+	ListReplace ListStrategy = iota
+	// ListAppend concatenates the lists, from the lowest to the highest priority item.
+	ListAppend
+)
+
+// Merge deep-merges the items sharing the same key into a single item, keeping lists from the highest priority item.
+// See MergeWith.
+func (s *ItemFilter) Merge() *ItemFilter {
+	return s.MergeWith(ListReplace)
+}
+
+// MergeWith deep-merges the (JSON or YAML) object values of the items sharing the same key, in priority order:
+// the fields of higher priority items override those of lower priority items.
+// Items sharing the same priority are ordered by provider registration order (see TieBreakRegistrationOrder),
+// then by value, so that the result is deterministic.
+// The result is a single item per key, holding the merged object as JSON, with the highest priority.
+// When the highest priority value is not an object, or when it carries an error, the key is squashed instead (see Squash).
+// Lower priority items carrying an error, or whose value cannot be parsed, are skipped.
+func (s *ItemFilter) MergeWith(lists ListStrategy) *ItemFilter {
+	return s.MergeWithTieBreak(lists, TieBreakRegistrationOrder)
+}
+
+// MergeWithTieBreak is similar to MergeWith, ordering the items sharing the same priority with the given tie-break
+// (see SquashWith), then by value.
+func (s *ItemFilter) MergeWithTieBreak(lists ListStrategy, tieBreak TieBreak) *ItemFilter {
+
+	s = copyItemFilter(s)
+
+	s.funcs = append(s.funcs, func(s *ItemList) *ItemList {
+		ret := &ItemList{}
+		for _, l := range s.indexed {
+			if len(l) > 0 {
+				ret.Items = append(ret.Items, mergeItems(l, lists, tieBreak))
+			}
+		}
+		return ret.index()
+	})
+
+	return s
+}
+
+// Merges items, which are expected to be sorted by descending priority.
+func mergeItems(l []Item, lists ListStrategy, tieBreak TieBreak) Item {
+	if len(l) == 1 {
+		return l[0]
+	}
+
+	l = append([]Item(nil), l...)
+	sort.SliceStable(l, func(i, j int) bool {
+		if l[i].outranks(&l[j]) || l[j].outranks(&l[i]) {
+			return l[i].outranks(&l[j])
+		}
+		if tieBreak != nil && (tieBreak(&l[i], &l[j]) || tieBreak(&l[j], &l[i])) {
+			return tieBreak(&l[i], &l[j])
+		}
+		return l[i].value > l[j].value
+	})
+	highest := l[0]
+
+	values := []interface{}{}
+	for i, it := range l {
+		var v interface{}
+		if it.unmarshalErr == nil && yaml.Unmarshal([]byte(it.value), &v) == nil {
+			values = append(values, v)
+			continue
+		}
+		if i == 0 {
+			return highest
+		}
+		// unusable lower priority value
+	}
+	if _, ok := values[0].(map[string]interface{}); !ok {
+		return highest
+	}
+
+	stale, sensitive := false, false
+	for _, it := range l {
+		stale = stale || it.stale
+		sensitive = sensitive || it.sensitive
+	}
+
+	var merged interface{}
+	for i := len(values) - 1; i >= 0; i-- {
+		merged = mergeValues(merged, values[i], lists)
+	}
+
+	j, err := json.Marshal(merged)
+	return Item{key: highest.key, value: string(j), priority: highest.priority, unmarshalErr: err, provider: highest.provider, layer: highest.layer, stale: stale, sensitive: sensitive, revision: highest.revision}
+}
+
+// Merges override into base. Objects are merged recursively, lists according to the strategy,
+// anything else is replaced.
+func mergeValues(base, override interface{}, lists ListStrategy) interface{} {
+	switch o := override.(type) {
+	case map[string]interface{}:
+		b, ok := base.(map[string]interface{})
+		if !ok {
+			return o
+		}
+		ret := make(map[string]interface{}, len(b)+len(o))
+		for k, v := range b {
+			ret[k] = v
+		}
+		for k, v := range o {
+			ret[k] = mergeValues(b[k], v, lists)
+		}
+		return ret
+	case []interface{}:
+		b, ok := base.([]interface{})
+		if !ok || lists != ListAppend {
+			return o
+		}
+		ret := make([]interface{}, 0, len(b)+len(o))
+		return append(append(ret, b...), o...)
+	}
+	return override
+}
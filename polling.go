@@ -0,0 +1,99 @@
+package configstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Polling registers a configstore provider which calls fetch every interval, and serves its last successful result.
+// Watchers are notified only when the fetched item set actually changes.
+// A failing fetch is logged, and the previous items are kept. Until the first successful fetch, the provider
+// returns the fetch error.
+// The first fetch happens synchronously. Polling stops when the returned provider is closed (see UnregisterProvider and Shutdown),
+// which also cancels the context passed to fetch. A non-positive interval defaults to 10s.
+func Polling(name string, interval time.Duration, fetch func(context.Context) ([]Item, error)) *InMemoryProvider {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	inmem := InMemory(name)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-inmem.Done()
+		cancel()
+	}()
+
+	last := ""
+	poll := func(notify bool) {
+		items, err := fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logrus.Errorf("configstore: provider '%s': %s", name, err)
+			if last == "" {
+				inmem.SetError(err)
+			}
+			return
+		}
+		h := hashItems(items)
+		if h == last {
+			return
+		}
+		last = h
+		inmem.Set(items...)
+		if notify {
+			NotifyWatchers()
+		}
+	}
+
+	poll(false)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				poll(true)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return inmem
+}
+
+// Registers a provider serving the result of a single call to fetch, for providers whose polling is disabled.
+func fetchOnce(name string, fetch func(context.Context) ([]Item, error)) *InMemoryProvider {
+	inmem := InMemory(name)
+	items, err := fetch(context.Background())
+	if err != nil {
+		logrus.Errorf("configstore: provider '%s': %s", name, err)
+		inmem.SetError(err)
+		return inmem
+	}
+	inmem.Set(items...)
+	return inmem
+}
+
+// Returns a hash of the item set, independent of the item order.
+func hashItems(items []Item) string {
+	lines := make([]string, len(items))
+	for i, it := range items {
+		j, _ := json.Marshal(it)
+		lines[i] = string(j)
+	}
+	sort.Strings(lines)
+	h := sha256.New()
+	for _, l := range lines {
+		fmt.Fprintln(h, l)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
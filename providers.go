@@ -2,8 +2,9 @@ package configstore
 
 import (
 	"fmt"
-	"io/ioutil"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sync"
 	"time"
@@ -45,23 +46,54 @@ func file(filename string, refresh bool, fn func([]byte) ([]Item, error)) {
 		return
 	}
 
-	providername := fmt.Sprintf("file:%s", filename)
+	fileFS(os.DirFS(filepath.Dir(filename)), filepath.Base(filename), filename, refresh, fn)
+}
+
+// FileFS is similar to File, reading the file from fsys instead of the OS filesystem, e.g. an embed.FS holding the
+// default configuration of the application.
+// name identifies fsys in the provider name ("file:<name>:<filename>"), so that files with the same name can be read
+// from several filesystems; it defaults to "fs".
+func FileFS(name string, fsys fs.FS, filename string) {
+	if filename == "" {
+		return
+	}
+	fileFS(fsys, filename, fsDisplay(name, filename), false, nil)
+}
+
+// Returns the display name of a file read from the filesystem identified by name.
+func fsDisplay(name, filename string) string {
+	if name == "" {
+		name = "fs"
+	}
+	return fmt.Sprintf("%s:%s", name, filename)
+}
+
+// Reads filename from fsys, display is the name of the file in logs and in the provider name.
+func fileFS(fsys fs.FS, filename, display string, refresh bool, fn func([]byte) ([]Item, error)) {
+
+	providername := fmt.Sprintf("file:%s", display)
 
 	last := time.Now()
-	vals, err := readFile(filename, fn)
+	vals, err := readFileFS(fsys, filename, fn)
 	if err != nil {
 		ErrorProvider(providername, err)
 		return
 	}
 	inmem := InMemory(providername)
-	logrus.Infof("Configuration from file: %s", filename)
+	logrus.Infof("Configuration from file: %s", display)
 	inmem.Add(vals...)
 
 	if refresh {
 		go func() {
 			ticker := time.NewTicker(10 * time.Second)
-			for range ticker.C {
-				finfo, err := os.Stat(filename)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+				case <-inmem.Done():
+					return
+				}
+				finfo, err := fs.Stat(fsys, filename)
 				if err != nil {
 					continue
 				}
@@ -70,13 +102,11 @@ func file(filename string, refresh bool, fn func([]byte) ([]Item, error)) {
 				} else {
 					continue
 				}
-				vals, err := readFile(filename, fn)
+				vals, err := readFileFS(fsys, filename, fn)
 				if err != nil {
 					continue
 				}
-				inmem.mut.Lock()
-				inmem.items = vals
-				inmem.mut.Unlock()
+				inmem.Set(vals...)
 				NotifyWatchers()
 			}
 		}()
@@ -95,9 +125,23 @@ func FileTree(dirname string) {
 		return
 	}
 
-	providername := fmt.Sprintf("filetree:%s", dirname)
+	fileTreeFS(os.DirFS(dirname), ".", dirname)
+}
 
-	files, err := ioutil.ReadDir(dirname)
+// FileTreeFS is similar to FileTree, reading the directory from fsys instead of the OS filesystem.
+// name identifies fsys in the provider name ("filetree:<name>:<dirname>"), see FileFS.
+func FileTreeFS(name string, fsys fs.FS, dirname string) {
+	if dirname == "" {
+		return
+	}
+	fileTreeFS(fsys, dirname, fsDisplay(name, dirname))
+}
+
+func fileTreeFS(fsys fs.FS, dirname, display string) {
+
+	providername := fmt.Sprintf("filetree:%s", display)
+
+	files, err := fs.ReadDir(fsys, dirname)
 	if err != nil {
 		ErrorProvider(providername, err)
 		return
@@ -106,16 +150,16 @@ func FileTree(dirname string) {
 	items := []Item{}
 
 	for _, f := range files {
-		filename := filepath.Join(dirname, f.Name())
+		filename := path.Join(dirname, f.Name())
 
 		if f.IsDir() {
-			items, err = browseDir(items, filename, f.Name())
+			items, err = browseDir(items, fsys, filename, f.Name())
 			if err != nil {
 				ErrorProvider(providername, err)
 				return
 			}
 		} else {
-			it, err := readItem(filename, f.Name(), f.Name())
+			it, err := readItem(fsys, filename, f.Name(), f.Name())
 			if err != nil {
 				ErrorProvider(providername, err)
 				return
@@ -138,30 +182,45 @@ func FileList(dirname string) {
 		return
 	}
 
-	files, err := ioutil.ReadDir(dirname)
+	fileListFS(os.DirFS(dirname), ".", dirname, filepath.Join)
+}
+
+// FileListFS is similar to FileList, reading the directory from fsys instead of the OS filesystem.
+// name identifies fsys in the provider names ("file:<name>:<dirname>/<filename>"), see FileFS.
+func FileListFS(name string, fsys fs.FS, dirname string) {
+	if dirname == "" {
+		return
+	}
+	fileListFS(fsys, dirname, fsDisplay(name, dirname), path.Join)
+}
+
+// join builds the display name of the files, from the display name of the directory.
+func fileListFS(fsys fs.FS, dirname, display string, join func(...string) string) {
+
+	files, err := fs.ReadDir(fsys, dirname)
 	if err != nil {
-		ErrorProvider(fmt.Sprintf("filelist:%s", dirname), err)
+		ErrorProvider(fmt.Sprintf("filelist:%s", display), err)
 		return
 	}
 
 	for _, file := range files {
-		File(filepath.Join(dirname, file.Name()))
+		fileFS(fsys, path.Join(dirname, file.Name()), join(display, file.Name()), false, nil)
 	}
 }
 
-func browseDir(items []Item, path, basename string) ([]Item, error) {
+func browseDir(items []Item, fsys fs.FS, dirname, basename string) ([]Item, error) {
 
-	files, err := ioutil.ReadDir(path)
+	files, err := fs.ReadDir(fsys, dirname)
 	if err != nil {
 		return items, err
 	}
 
 	for _, f := range files {
-		filename := filepath.Join(path, f.Name())
+		filename := path.Join(dirname, f.Name())
 		if f.IsDir() {
 			return items, fmt.Errorf("subdir %s: encountered nested directory %s, max 1 level of nesting", basename, f.Name())
 		}
-		it, err := readItem(filename, f.Name(), basename)
+		it, err := readItem(fsys, filename, f.Name(), basename)
 		if err != nil {
 			return items, err
 		}
@@ -171,30 +230,42 @@ func browseDir(items []Item, path, basename string) ([]Item, error) {
 	return items, nil
 }
 
-func readItem(path, basename, itemKey string) (Item, error) {
-	content, err := ioutil.ReadFile(path)
+func readItem(fsys fs.FS, filename, basename, itemKey string) (Item, error) {
+	content, err := fs.ReadFile(fsys, filename)
 	if err != nil {
 		return Item{}, err
 	}
-	priority := int64(5)
+	return NewItem(itemKey, string(content), filePriority(basename)), nil
+}
+
+// Returns the priority of an item read from a plain file: capitalized = higher priority.
+func filePriority(basename string) int64 {
 	first, _ := utf8.DecodeRuneInString(basename)
 	if unicode.IsUpper(first) {
-		priority = 10
+		return 10
 	}
-	return NewItem(itemKey, string(content), priority), nil
+	return 5
 }
 
 func readFile(filename string, fn func([]byte) ([]Item, error)) ([]Item, error) {
-	vals := []Item{}
-	b, err := ioutil.ReadFile(filename)
+	return readFileFS(os.DirFS(filepath.Dir(filename)), filepath.Base(filename), fn)
+}
+
+func readFileFS(fsys fs.FS, filename string, fn func([]byte) ([]Item, error)) ([]Item, error) {
+	b, err := fs.ReadFile(fsys, filename)
 	if err != nil {
 		return nil, err
 	}
+	return unmarshalItems(b, fn)
+}
 
+// Unmarshals a JSON/YAML item list, or uses the custom unmarshal function fn if not nil.
+func unmarshalItems(b []byte, fn func([]byte) ([]Item, error)) ([]Item, error) {
 	if fn != nil {
 		return fn(b)
 	}
-	err = yaml.Unmarshal(b, &vals)
+	vals := []Item{}
+	err := yaml.Unmarshal(b, &vals)
 	if err != nil {
 		return nil, err
 	}
@@ -203,8 +274,10 @@ func readFile(filename string, fn func([]byte) ([]Item, error)) ([]Item, error)
 
 // InMemoryProvider implements an in-memory configstore provider.
 type InMemoryProvider struct {
-	items []Item
-	mut   sync.Mutex
+	items  []Item
+	err    error
+	mut    sync.Mutex
+	closed chan struct{}
 }
 
 // Add appends an item to the in-memory list.
@@ -215,17 +288,66 @@ func (inmem *InMemoryProvider) Add(s ...Item) *InMemoryProvider {
 	return inmem
 }
 
+// Set replaces the in-memory list, and clears the error (see SetError).
+func (inmem *InMemoryProvider) Set(s ...Item) *InMemoryProvider {
+	inmem.mut.Lock()
+	defer inmem.mut.Unlock()
+	inmem.items = s
+	inmem.err = nil
+	return inmem
+}
+
+// SetError sets the error returned by Items, until the next call to Set.
+func (inmem *InMemoryProvider) SetError(err error) *InMemoryProvider {
+	inmem.mut.Lock()
+	defer inmem.mut.Unlock()
+	inmem.err = err
+	return inmem
+}
+
 // Items returns the in-memory item list. This is the function that gets called by configstore.
 func (inmem *InMemoryProvider) Items() (ItemList, error) {
 	inmem.mut.Lock()
 	defer inmem.mut.Unlock()
+	if inmem.err != nil {
+		return ItemList{}, inmem.err
+	}
 	return ItemList{Items: inmem.items}, nil
 }
 
+// Close signals the background goroutines feeding the provider (e.g. FileRefresh) to stop, see Done().
+// The items remain available. It implements io.Closer, and gets called by UnregisterProvider and Shutdown.
+func (inmem *InMemoryProvider) Close() error {
+	inmem.mut.Lock()
+	defer inmem.mut.Unlock()
+	select {
+	case <-inmem.done():
+	default:
+		close(inmem.done())
+	}
+	return nil
+}
+
+// Done returns a channel which gets closed when the provider is closed.
+func (inmem *InMemoryProvider) Done() <-chan struct{} {
+	inmem.mut.Lock()
+	defer inmem.mut.Unlock()
+	return inmem.done()
+}
+
+// NOT CONCURRENT SAFE, inmem.mut must be held.
+func (inmem *InMemoryProvider) done() chan struct{} {
+	if inmem.closed == nil {
+		inmem.closed = make(chan struct{})
+	}
+	return inmem.closed
+}
+
 // InMemory registers an InMemoryProvider with a given arbitrary name and returns it.
 // You can append any number of items to it, see Add().
 func InMemory(name string) *InMemoryProvider {
 	inmem := &InMemoryProvider{}
 	RegisterProvider(name, inmem.Items)
+	RegisterProviderCloser(name, inmem)
 	return inmem
 }
@@ -0,0 +1,89 @@
+package configstore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS(t *testing.T) {
+	assert := assert.New(t)
+
+	fsys := fstest.MapFS{
+		"defaults.yml":       {Data: []byte("- key: fs.file\n  value: file\n")},
+		"list/a.yml":         {Data: []byte("- key: fs.list.a\n  value: a\n")},
+		"list/b.json":        {Data: []byte(`[{"key": "fs.list.b", "value": "b", "priority": 3}]`)},
+		"tree/fs.tree":       {Data: []byte("top")},
+		"tree/fs.sub/low":    {Data: []byte("low")},
+		"tree/fs.sub/High":   {Data: []byte("high")},
+		"nested/a/b/c":       {Data: []byte("too deep")},
+		"invalid/broken.yml": {Data: []byte("{")},
+	}
+
+	FileFS("", fsys, "defaults.yml")
+	defer UnregisterProvider("file:fs:defaults.yml")
+	FileListFS("", fsys, "list")
+	defer UnregisterProvider("file:fs:list/a.yml")
+	defer UnregisterProvider("file:fs:list/b.json")
+	FileTreeFS("", fsys, "tree")
+	defer UnregisterProvider("filetree:fs:tree")
+
+	items, err := GetItemList()
+	assert.NoError(err)
+	assert.Equal("file", mustValue(must(items.GetItem("fs.file")).(Item)))
+	assert.Equal("a", mustValue(must(items.GetItem("fs.list.a")).(Item)))
+	i := must(items.GetItem("fs.list.b")).(Item)
+	assert.Equal(int64(3), i.Priority())
+	assert.Equal("file:fs:list/b.json", i.Provider())
+	assert.Equal("top", mustValue(must(items.GetItem("fs.tree")).(Item)))
+	sub := must(Filter().Slice("fs.sub").GetItemList()).(*ItemList)
+	if assert.Len(sub.Items, 2) {
+		assert.Equal("high", mustValue(sub.Items[0]))
+		assert.Equal("low", mustValue(sub.Items[1]))
+	}
+
+	FileTreeFS("", fsys, "nested")
+	_, err = providers["filetree:fs:nested"]()
+	assert.EqualError(err, "subdir a: encountered nested directory b, max 1 level of nesting")
+	UnregisterProvider("filetree:fs:nested")
+
+	FileFS("", fsys, "invalid/broken.yml")
+	_, err = providers["file:fs:invalid/broken.yml"]()
+	assert.Error(err)
+	UnregisterProvider("file:fs:invalid/broken.yml")
+
+	// the same file name from another filesystem
+	other := fstest.MapFS{"defaults.yml": {Data: []byte("- key: fs.other\n  value: other\n")}}
+	FileFS("other", other, "defaults.yml")
+	defer UnregisterProvider("file:other:defaults.yml")
+	i = must(GetItem("fs.other")).(Item)
+	assert.Equal("file:other:defaults.yml", i.Provider())
+	assert.Equal("file", must(GetItemValue("fs.file")))
+}
+
+func TestFileList(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "filelist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "a.yml"), []byte("- key: os.list\n  value: a\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	FileList(dir)
+	defer UnregisterProvider("file:" + filepath.Join(dir, "a.yml"))
+
+	items, err := GetItemList()
+	assert.NoError(err)
+	i := must(items.GetItem("os.list")).(Item)
+	assert.Equal("a", mustValue(i))
+	assert.Equal("file:"+filepath.Join(dir, "a.yml"), i.Provider())
+}
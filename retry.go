@@ -0,0 +1,128 @@
+package configstore
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RetryPolicy describes how WithRetry retries a failing provider: exponential backoff with jitter.
+// Zero fields get sensible defaults.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of calls, including the first one (default 3).
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry (default 100ms).
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts (default 10s).
+	MaxBackoff time.Duration
+	// Multiplier is applied to the delay after each retry (default 2).
+	Multiplier float64
+	// Jitter randomizes each delay by +/- this fraction of its value, between 0 and 1 (default 0: no jitter).
+	Jitter float64
+	// MaxElapsed caps the total duration of a call, retries included: no retry is attempted if it would start
+	// after that duration (default 0: no limit besides MaxAttempts).
+	MaxElapsed time.Duration
+	// OnAttempt, if set, is called after each failed attempt (1-based), e.g. to feed metrics.
+	OnAttempt func(attempt int, err error)
+}
+
+// WithRetry wraps the provider p: when it fails, it gets called again according to the policy.
+// The error of the last attempt is returned if they all fail.
+// Retries happen within the provider call, while the configuration is being read: every reader (GetItemList, GetItem, ...)
+// is blocked until they are over. Keep the budget short (see MaxElapsed), or use Polling with WithRetryFetch to retry
+// in the background while readers get served the last successful items.
+// Waiting for the next attempt is interrupted by Shutdown, in which case the error of the last attempt is returned.
+func WithRetry(p Provider, policy RetryPolicy) Provider {
+	return func() (ItemList, error) {
+		return retry(context.Background(), policy, func(context.Context) (ItemList, error) {
+			return p()
+		})
+	}
+}
+
+// WithRetryFetch wraps a fetch function (see Polling) the same way WithRetry wraps a provider.
+// The context of each call is passed to fetch, and also bounds the retries: when it expires or gets cancelled,
+// the error of the last attempt is returned without waiting for the next one.
+func WithRetryFetch(fetch func(context.Context) ([]Item, error), policy RetryPolicy) func(context.Context) ([]Item, error) {
+	return func(ctx context.Context) ([]Item, error) {
+		l, err := retry(ctx, policy, func(ctx context.Context) (ItemList, error) {
+			items, err := fetch(ctx)
+			return ItemList{Items: items}, err
+		})
+		return l.Items, err
+	}
+}
+
+// Calls f until it succeeds, the policy gives up, ctx is done or the library shuts down.
+func retry(ctx context.Context, policy RetryPolicy, f func(context.Context) (ItemList, error)) (ItemList, error) {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 3
+	}
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = 100 * time.Millisecond
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = 10 * time.Second
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = 2
+	}
+
+	start := time.Now()
+	backoff := policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		l, err := f(ctx)
+		if err == nil {
+			return l, nil
+		}
+		if policy.OnAttempt != nil {
+			policy.OnAttempt(attempt, err)
+		}
+		if attempt >= policy.MaxAttempts {
+			logrus.Warnf("configstore: provider failed after %d attempts: %s", attempt, err)
+			return l, err
+		}
+
+		delay := jitter(backoff, policy.Jitter)
+		if policy.MaxElapsed > 0 && time.Since(start)+delay > policy.MaxElapsed {
+			logrus.Warnf("configstore: provider failed after %d attempts, retry budget exceeded: %s", attempt, err)
+			return l, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			logrus.Warnf("configstore: provider failed after %d attempts, context deadline exceeded: %s", attempt, err)
+			return l, err
+		}
+		logrus.Debugf("configstore: provider attempt %d failed, retrying in %s: %s", attempt, delay, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			logrus.Warnf("configstore: provider failed after %d attempts, %s: %s", attempt, ctx.Err(), err)
+			return l, err
+		case <-shutdownCh:
+			timer.Stop()
+			logrus.Warnf("configstore: provider failed after %d attempts, shutting down: %s", attempt, err)
+			return l, err
+		}
+
+		backoff = time.Duration(float64(backoff) * policy.Multiplier)
+		if backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+// Randomizes d by +/- the given fraction of its value.
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	delta := float64(d) * fraction * (2*rand.Float64() - 1)
+	return d + time.Duration(delta)
+}
@@ -0,0 +1,195 @@
+package configstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RedactedValue replaces the value of sensitive items exposed by Handler.
+const RedactedValue = "[REDACTED]"
+
+// ServerOptions customizes the configuration server, see Handler.
+type ServerOptions struct {
+	// Redact flags additional items as sensitive, on top of those flagged by their provider or a filter (see Item.Sensitive).
+	Redact func(*Item) bool
+	// MaxWait caps the duration of long-poll requests (default 5m).
+	MaxWait time.Duration
+}
+
+// Handler returns an http.Handler exposing the configuration, so that other processes can consume it
+// (e.g. via the HTTP provider). It serves the item list as JSON, in the same format as the File provider.
+// The values of sensitive items are always replaced by RedactedValue.
+//
+// Query parameters:
+//   - filter=<name> applies a filter registered via RegisterFilter instead of serving the full list.
+//   - wait=<duration> long-polls: if the request carries an If-None-Match header matching the current ETag,
+//     the response is delayed until the configuration changes (see Watch), or 304 is returned once the duration elapsed.
+//
+// Requests accepting "text/event-stream" get a Server-Sent Events stream instead, with an "items" event
+// sent initially then on each configuration change.
+func Handler(opts ServerOptions) http.Handler {
+	if opts.MaxWait <= 0 {
+		opts.MaxWait = 5 * time.Minute
+	}
+	return &server{opts: opts}
+}
+
+type server struct {
+	opts ServerOptions
+}
+
+func (srv *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var filter *ItemFilter
+	if name := r.URL.Query().Get("filter"); name != "" {
+		f, ok := LookupFilter(name)
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown filter: %s", name), http.StatusNotFound)
+			return
+		}
+		filter = f
+	}
+
+	if acceptsEventStream(r) {
+		srv.serveEvents(w, r, filter)
+		return
+	}
+
+	var wait time.Duration
+	if s := r.URL.Query().Get("wait"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid wait duration: %s", err), http.StatusBadRequest)
+			return
+		}
+		wait = d
+		if wait > srv.opts.MaxWait {
+			wait = srv.opts.MaxWait
+		}
+	}
+
+	// subscribe before reading the list, to not miss a change happening in between
+	ch := Watch()
+	defer Unwatch(ch)
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+
+	for {
+		b, etag, err := srv.render(filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		if etag != r.Header.Get("If-None-Match") {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("ETag", etag)
+			w.Write(b)
+			return
+		}
+		select {
+		case _, ok := <-ch:
+			if ok {
+				continue
+			}
+		case <-timeout.C:
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+}
+
+func (srv *server) serveEvents(w http.ResponseWriter, r *http.Request, filter *ItemFilter) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusNotImplemented)
+		return
+	}
+
+	ch := Watch()
+	defer Unwatch(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	last := ""
+	for {
+		b, etag, err := srv.render(filter)
+		if err != nil {
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", jsonString(err.Error()))
+		} else if etag != last {
+			last = etag
+			fmt.Fprintf(w, "id: %s\nevent: items\ndata: %s\n\n", etag, b)
+		}
+		flusher.Flush()
+
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// Returns the redacted JSON representation of the item list, along with its ETag.
+func (srv *server) render(filter *ItemFilter) ([]byte, string, error) {
+	items, err := GetItemList()
+	if err != nil {
+		return nil, "", err
+	}
+	items = filter.Apply(items)
+
+	redacted := make([]Item, len(items.Items))
+	for i, it := range items.Items {
+		if it.sensitive || (srv.opts.Redact != nil && srv.opts.Redact(&it)) {
+			it.value = RedactedValue
+			it.sensitive = true
+		}
+		redacted[i] = it
+	}
+
+	b, err := json.Marshal(redacted)
+	if err != nil {
+		return nil, "", err
+	}
+	return b, fmt.Sprintf(`"%s"`, hashItems(redacted)), nil
+}
+
+// Returns true if the Accept header of the request lists "text/event-stream", with a non-zero quality.
+func acceptsEventStream(r *http.Request) bool {
+	for _, v := range r.Header.Values("Accept") {
+		for _, accepted := range strings.Split(v, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+			if err != nil || mediaType != "text/event-stream" {
+				continue
+			}
+			if q, ok := params["q"]; ok {
+				f, err := strconv.ParseFloat(q, 64)
+				if err != nil || f <= 0 {
+					continue
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -0,0 +1,115 @@
+package configstore
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer(t *testing.T) {
+	assert := assert.New(t)
+
+	inmem := InMemory("server-test").Add(
+		NewItem("server-key", "value", 0),
+		NewSensitiveItem("server-password", "hunter2", 0),
+		NewItem("server-token", "abcd", 0),
+	)
+	defer UnregisterProvider("server-test")
+	RegisterFilter("server-filter", Filter().SlicePrefix("server-"), "server test items")
+
+	srv := httptest.NewServer(Handler(ServerOptions{
+		Redact: func(i *Item) bool { return strings.HasSuffix(i.Key(), "-token") },
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?filter=server-filter")
+	assert.NoError(err)
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	items, err := unmarshalItems(b, nil)
+	assert.NoError(err)
+	list := (&ItemList{Items: items}).index()
+	assert.Equal("value", must(list.GetItemValue("server-key")))
+	assert.Equal(RedactedValue, must(list.GetItemValue("server-password")))
+	assert.Equal(RedactedValue, must(list.GetItemValue("server-token")))
+	etag := resp.Header.Get("ETag")
+	assert.NotEmpty(etag)
+
+	resp, err = http.Get(srv.URL + "?filter=unknown")
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+
+	// conditional request, no change
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"?filter=server-filter", nil)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusNotModified, resp.StatusCode)
+
+	// long-poll, woken up by a change
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		inmem.Add(NewItem("server-new", "new", 0))
+		NotifyWatchers()
+	}()
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"?filter=server-filter&wait=10s", nil)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(err)
+	b, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Contains(string(b), "server-new")
+
+	// server-sent events
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"?filter=server-filter", nil)
+	req.Header.Set("Accept", "text/event-stream, */*;q=0.1")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(err)
+	defer resp.Body.Close()
+	assert.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+	r := bufio.NewReader(resp.Body)
+	events := []string{}
+	for len(events) < 2 {
+		line, err := r.ReadString('\n')
+		if !assert.NoError(err) {
+			break
+		}
+		if strings.HasPrefix(line, "data: ") {
+			events = append(events, line)
+			if len(events) == 1 {
+				inmem.Add(NewItem("server-sse", "sse", 0))
+				NotifyWatchers()
+			}
+		}
+	}
+	assert.NotContains(events[0], "server-sse")
+	assert.Contains(events[1], "server-sse")
+	assert.NotContains(events[1], "hunter2")
+}
+
+func TestAcceptsEventStream(t *testing.T) {
+	assert := assert.New(t)
+
+	for accept, expected := range map[string]bool{
+		"text/event-stream":                   true,
+		"text/event-stream; charset=utf-8":    true,
+		"application/json, text/event-stream": true,
+		"text/event-stream;q=0, */*":          false,
+		"application/json":                    false,
+		"":                                    false,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", accept)
+		assert.Equal(expected, acceptsEventStream(r), accept)
+	}
+}
@@ -0,0 +1,91 @@
+package configstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SQLOptions customizes the SQL provider, see SQL.
+type SQLOptions struct {
+	// Interval between two polls. If zero, the query runs only once.
+	Interval time.Duration
+	// VersionQuery returns a single value changing along with the items, e.g. "SELECT MAX(updated_at) FROM settings".
+	// If set, the items query only runs again when that value changes. Otherwise it runs on each poll.
+	VersionQuery string
+}
+
+// SQL registers a configstore provider which reads the items from a relational database.
+// The query must return the key, value and priority columns, in that order; the priority column is optional (default 0),
+// and NULL values are read as empty.
+// If opts.Interval is set, the query runs again periodically, and watchers get notified when the items change.
+func SQL(name string, db *sql.DB, query string, opts SQLOptions) *InMemoryProvider {
+	name = fmt.Sprintf("sql:%s", name)
+
+	version := ""
+	var last []Item
+
+	fetch := func(ctx context.Context) ([]Item, error) {
+		if opts.VersionQuery != "" {
+			var v interface{}
+			err := db.QueryRowContext(ctx, opts.VersionQuery).Scan(&v)
+			if err != nil {
+				return nil, fmt.Errorf("version query: %s", err)
+			}
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			newVersion := fmt.Sprint(v)
+			if last != nil && newVersion == version {
+				return last, nil
+			}
+			items, err := querySQLItems(ctx, db, query)
+			if err != nil {
+				return nil, err
+			}
+			version = newVersion
+			last = items
+			return items, nil
+		}
+		return querySQLItems(ctx, db, query)
+	}
+
+	if opts.Interval > 0 {
+		return Polling(name, opts.Interval, fetch)
+	}
+	return fetchOnce(name, fetch)
+}
+
+func querySQLItems(ctx context.Context, db *sql.DB, query string) ([]Item, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if len(cols) != 2 && len(cols) != 3 {
+		return nil, fmt.Errorf("expected key, value and priority columns, got %d columns", len(cols))
+	}
+
+	items := []Item{}
+	for rows.Next() {
+		var key string
+		var value sql.NullString
+		var priority sql.NullInt64
+		dest := []interface{}{&key, &value}
+		if len(cols) == 3 {
+			dest = append(dest, &priority)
+		}
+		err := rows.Scan(dest...)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, NewItem(key, value.String, priority.Int64))
+	}
+	return items, rows.Err()
+}
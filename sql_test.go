@@ -0,0 +1,69 @@
+package configstore
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func TestSQL(t *testing.T) {
+	assert := assert.New(t)
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// a single connection: each connection gets its own in-memory database
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TABLE settings (key TEXT, value TEXT, priority INTEGER, updated_at INTEGER);
+		INSERT INTO settings VALUES ('sql.timeout', '10s', 5, 1), ('sql.empty', NULL, NULL, 1)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := SQL("settings", db, "SELECT key, value, priority FROM settings ORDER BY key", SQLOptions{
+		Interval:     20 * time.Millisecond,
+		VersionQuery: "SELECT MAX(updated_at) FROM settings",
+	})
+	defer UnregisterProvider("sql:settings")
+
+	l, err := p.Items()
+	assert.NoError(err)
+	if assert.Len(l.Items, 2) {
+		assert.Equal("sql.empty", l.Items[0].Key())
+		assert.Equal("", mustValue(l.Items[0]))
+		assert.Equal(int64(0), l.Items[0].Priority())
+		assert.Equal("10s", mustValue(l.Items[1]))
+		assert.Equal(int64(5), l.Items[1].Priority())
+	}
+
+	ch := Watch()
+	defer Unwatch(ch)
+
+	// not picked up: the version is unchanged
+	_, err = db.Exec(`UPDATE settings SET value = '20s' WHERE key = 'sql.timeout'`)
+	assert.NoError(err)
+	time.Sleep(100 * time.Millisecond)
+	l, _ = p.Items()
+	assert.Equal("10s", mustValue(l.Items[1]))
+
+	_, err = db.Exec(`UPDATE settings SET value = '30s', updated_at = 2 WHERE key = 'sql.timeout'`)
+	assert.NoError(err)
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("watchers not notified")
+	}
+	l, _ = p.Items()
+	assert.Equal("30s", mustValue(l.Items[1]))
+
+	p = SQL("invalid", db, "SELECT key FROM settings", SQLOptions{})
+	defer UnregisterProvider("sql:invalid")
+	_, err = p.Items()
+	assert.EqualError(err, "expected key, value and priority columns, got 1 columns")
+}
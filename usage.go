@@ -0,0 +1,106 @@
+package configstore
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+var (
+	filters = map[string]registeredFilter{}
+	fMut    sync.Mutex
+)
+
+type registeredFilter struct {
+	name        string
+	filter      *ItemFilter
+	description string
+}
+
+// RegisterFilter declares a filter under an arbitrary name, along with a human readable description.
+// Registered filters are listed by Usage and UsageMarkdown, which lets main print the configuration
+// expected by every package of the application.
+func RegisterFilter(name string, f *ItemFilter, description string) *ItemFilter {
+	fMut.Lock()
+	defer fMut.Unlock()
+	_, ok := filters[name]
+	if ok {
+		panic(fmt.Sprintf("conflict on configuration filter: %s", name))
+	}
+	filters[name] = registeredFilter{name: name, filter: f, description: description}
+	return f
+}
+
+// LookupFilter returns a filter registered via RegisterFilter, by name.
+func LookupFilter(name string) (*ItemFilter, bool) {
+	fMut.Lock()
+	defer fMut.Unlock()
+	r, ok := filters[name]
+	return r.filter, ok
+}
+
+// Returns the registered filters, sorted by name.
+func registeredFilters() []registeredFilter {
+	fMut.Lock()
+	defer fMut.Unlock()
+	ret := make([]registeredFilter, 0, len(filters))
+	for _, f := range filters {
+		ret = append(ret, f)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].name < ret[j].name })
+	return ret
+}
+
+func (r registeredFilter) key() string {
+	if r.filter == nil || r.filter.initialKeySlice == "" {
+		return "[NONE]"
+	}
+	return r.filter.initialKeySlice
+}
+
+func (r registeredFilter) defaultValue() string {
+	if r.filter == nil || r.filter.defaultValue == nil {
+		return ""
+	}
+	return *r.filter.defaultValue
+}
+
+// Usage writes a description of all the registered filters to w: key, expected type, default value and description.
+func Usage(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tKEY\tTYPE\tDEFAULT\tDESCRIPTION")
+	for _, r := range registeredFilters() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.name, r.key(), r.filter.typeString(), r.defaultValue(), r.description)
+	}
+	return tw.Flush()
+}
+
+// UsageMarkdown writes a description of all the registered filters to w, as a Markdown table.
+func UsageMarkdown(w io.Writer) error {
+	_, err := fmt.Fprint(w, "| Name | Key | Type | Default | Description |\n|---|---|---|---|---|\n")
+	if err != nil {
+		return err
+	}
+	for _, r := range registeredFilters() {
+		_, err = fmt.Fprintf(w, "| %s | `%s` | `%s` | %s | %s |\n",
+			escapeMarkdown(r.name), escapeMarkdown(r.key()), escapeMarkdown(r.filter.typeString()), markdownDefault(r.defaultValue()), escapeMarkdown(r.description))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func markdownDefault(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + escapeMarkdown(s) + "`"
+}
+
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
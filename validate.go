@@ -0,0 +1,132 @@
+package configstore
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Validator can be implemented by the objects returned by the factory passed to ItemFilter.Unmarshal.
+// Validate gets called right after a successful unmarshal, and its error is stored within the item wrapped
+// in an ErrInvalidItem, like the struct tag violations. See item.Unmarshaled().
+type Validator interface {
+	Validate() error
+}
+
+// Validates the unmarshaled object i of the item identified by key: first the `validate:"..."` struct tags, then its Validate() method if present.
+// Supported tag rules (comma separated): required, min=N, max=N, oneof=a b c.
+// min/max apply to the value of numbers, and to the length of strings, slices and maps.
+func validate(key string, i interface{}) error {
+	field, err := validateTags(reflect.ValueOf(i), "")
+	if err != nil {
+		return ErrInvalidItem{Key: key, Field: field, Err: err}
+	}
+	if v, ok := i.(Validator); ok {
+		err := v.Validate()
+		if err != nil {
+			return ErrInvalidItem{Key: key, Err: err}
+		}
+	}
+	return nil
+}
+
+// Walks the struct fields recursively, returns the path of the first invalid field along with the error.
+func validateTags(v reflect.Value, path string) (string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", nil
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+		name := f.Name
+		if path != "" {
+			name = path + "." + f.Name
+		}
+		fv := v.Field(i)
+		tag := f.Tag.Get("validate")
+		if tag != "" && tag != "-" {
+			for _, rule := range strings.Split(tag, ",") {
+				err := validateRule(fv, strings.TrimSpace(rule))
+				if err != nil {
+					return name, err
+				}
+			}
+		}
+		field, err := validateTags(fv, name)
+		if err != nil {
+			return field, err
+		}
+	}
+	return "", nil
+}
+
+func validateRule(v reflect.Value, rule string) error {
+	parts := strings.SplitN(rule, "=", 2)
+	name := parts[0]
+	arg := ""
+	if len(parts) > 1 {
+		arg = parts[1]
+	}
+
+	switch name {
+	case "":
+		return nil
+	case "required":
+		if v.IsZero() {
+			return fmt.Errorf("required")
+		}
+	case "min", "max":
+		bound, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s rule '%s'", name, arg)
+		}
+		n, ok := measure(v)
+		if !ok {
+			return fmt.Errorf("%s rule not applicable to %s", name, v.Kind())
+		}
+		if name == "min" && n < bound {
+			return fmt.Errorf("%v is lower than min %s", n, arg)
+		}
+		if name == "max" && n > bound {
+			return fmt.Errorf("%v is greater than max %s", n, arg)
+		}
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, allowed := range strings.Fields(arg) {
+			if s == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("'%s' is not one of [%s]", s, arg)
+	default:
+		return fmt.Errorf("unknown rule '%s'", name)
+	}
+	return nil
+}
+
+// Returns the value of numbers, or the length of strings / slices / maps.
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	}
+	return 0, false
+}
@@ -0,0 +1,350 @@
+package configstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// VaultAddrEnvVar defines the environment variable used to set the Vault address (default https://127.0.0.1:8200).
+	VaultAddrEnvVar = "VAULT_ADDR"
+	// VaultTokenEnvVar defines the environment variable used to set the Vault token.
+	VaultTokenEnvVar = "VAULT_TOKEN"
+	// VaultNamespaceEnvVar defines the environment variable used to set the Vault namespace.
+	VaultNamespaceEnvVar = "VAULT_NAMESPACE"
+	// VaultRoleIDEnvVar defines the environment variable used to set the AppRole role ID, used when no token is set.
+	VaultRoleIDEnvVar = "VAULT_ROLE_ID"
+	// VaultSecretIDEnvVar defines the environment variable used to set the AppRole secret ID.
+	VaultSecretIDEnvVar = "VAULT_SECRET_ID"
+)
+
+// VaultOptions customizes the Vault provider, see VaultCustom.
+type VaultOptions struct {
+	// Address of the Vault server (default https://127.0.0.1:8200).
+	Address string
+	// Namespace sent with each request (Vault Enterprise).
+	Namespace string
+	// Token used to authenticate. If empty, an AppRole login is performed with RoleID and SecretID.
+	Token    string
+	RoleID   string
+	SecretID string
+	// JSON produces a single item per secret, keyed by the secret path and holding all its fields as a JSON object,
+	// instead of an item per field, keyed by the field name.
+	JSON bool
+	// Interval between two reads of the secrets (default 1m).
+	Interval time.Duration
+	// Client used for the requests (default: a client with a 10s timeout).
+	Client *http.Client
+}
+
+// Vault registers a configstore provider which reads the Vault KV v2 secret at the path given in parameter
+// ("<mount>/<path>", e.g. "secret/myapp"). Each field of the secret becomes a sensitive item (see Item.Sensitive),
+// keyed by the field name. The secret is read again every minute, and watchers get notified on change.
+// The address and credentials are read from the environment, see VaultAddrEnvVar, VaultTokenEnvVar and VaultRoleIDEnvVar.
+func Vault(path string) {
+	if path == "" {
+		return
+	}
+	VaultCustom([]string{path}, VaultOptions{
+		Address:   os.Getenv(VaultAddrEnvVar),
+		Namespace: os.Getenv(VaultNamespaceEnvVar),
+		Token:     os.Getenv(VaultTokenEnvVar),
+		RoleID:    os.Getenv(VaultRoleIDEnvVar),
+		SecretID:  os.Getenv(VaultSecretIDEnvVar),
+	})
+}
+
+// VaultCustom is similar to Vault, reading several secret paths with explicit options instead of the environment.
+// The token is renewed in the background while the provider is registered, or obtained again via AppRole when
+// renewal is not possible.
+func VaultCustom(paths []string, opts VaultOptions) *InMemoryProvider {
+	if opts.Address == "" {
+		opts.Address = "https://127.0.0.1:8200"
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	vc := &vaultClient{opts: opts, token: opts.Token, retryDelay: time.Second}
+	name := fmt.Sprintf("vault:%s", strings.Join(paths, ";"))
+
+	fetch := func(ctx context.Context) ([]Item, error) {
+		items := []Item{}
+		for _, p := range paths {
+			data, err := vc.readSecret(ctx, p)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", p, err)
+			}
+			if opts.JSON {
+				j, err := json.Marshal(data)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, NewSensitiveItem(strings.TrimPrefix(p, vaultMount(p)+"/"), string(j), 0))
+				continue
+			}
+			fields := make([]string, 0, len(data))
+			for f := range data {
+				fields = append(fields, f)
+			}
+			sort.Strings(fields)
+			for _, f := range fields {
+				v, ok := data[f].(string)
+				if !ok {
+					j, _ := json.Marshal(data[f])
+					v = string(j)
+				}
+				items = append(items, NewSensitiveItem(f, v, 0))
+			}
+		}
+		return items, nil
+	}
+
+	inmem := Polling(name, opts.Interval, fetch)
+	go vc.renewLoop(inmem.Done())
+	return inmem
+}
+
+type vaultClient struct {
+	opts VaultOptions
+
+	mut       sync.Mutex
+	token     string
+	renewable bool
+	// when the token should be renewed, zero if it does not expire
+	renewAt time.Time
+	// initial delay between two failed lookups of the static token
+	retryDelay time.Duration
+}
+
+// Returns the mount of a secret path: its first segment.
+func vaultMount(path string) string {
+	return strings.SplitN(strings.Trim(path, "/"), "/", 2)[0]
+}
+
+// Reads a KV v2 secret, returns its fields.
+func (vc *vaultClient) readSecret(ctx context.Context, path string) (map[string]interface{}, error) {
+	path = strings.Trim(path, "/")
+	mount := vaultMount(path)
+	apiPath := fmt.Sprintf("/v1/%s/data/%s", mount, strings.TrimPrefix(strings.TrimPrefix(path, mount), "/"))
+
+	resp := struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}{}
+	err := vc.do(ctx, http.MethodGet, apiPath, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Data.Data, nil
+}
+
+// Performs an authenticated request, logging in first if needed.
+func (vc *vaultClient) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	vc.mut.Lock()
+	token := vc.token
+	vc.mut.Unlock()
+	if token == "" {
+		err := vc.login(ctx)
+		if err != nil {
+			return err
+		}
+		vc.mut.Lock()
+		token = vc.token
+		vc.mut.Unlock()
+	}
+	return vc.request(ctx, method, path, token, body, out)
+}
+
+func (vc *vaultClient) request(ctx context.Context, method, path, token string, body interface{}, out interface{}) error {
+	var b []byte
+	if body != nil {
+		var err error
+		b, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(vc.opts.Address, "/")+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if vc.opts.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", vc.opts.Namespace)
+	}
+
+	resp, err := vc.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		e := struct {
+			Errors []string `json:"errors"`
+		}{}
+		json.NewDecoder(resp.Body).Decode(&e)
+		if len(e.Errors) > 0 {
+			return fmt.Errorf("vault: %s: %s", resp.Status, strings.Join(e.Errors, ", "))
+		}
+		return fmt.Errorf("vault: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+type vaultAuth struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int64  `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+// Obtains a token via AppRole.
+func (vc *vaultClient) login(ctx context.Context) error {
+	if vc.opts.RoleID == "" {
+		return errors.New("vault: no token nor AppRole role ID")
+	}
+	resp := vaultAuth{}
+	err := vc.request(ctx, http.MethodPost, "/v1/auth/approle/login", "", map[string]string{
+		"role_id":   vc.opts.RoleID,
+		"secret_id": vc.opts.SecretID,
+	}, &resp)
+	if err != nil {
+		return err
+	}
+	vc.setAuth(resp)
+	return nil
+}
+
+func (vc *vaultClient) setAuth(a vaultAuth) {
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+	if a.Auth.ClientToken != "" {
+		vc.token = a.Auth.ClientToken
+	}
+	vc.renewable = a.Auth.Renewable
+	vc.renewAt = time.Time{}
+	if a.Auth.LeaseDuration > 0 && (vc.renewable || vc.opts.RoleID != "") {
+		vc.renewAt = time.Now().Add(time.Duration(a.Auth.LeaseDuration) * time.Second / 2)
+	}
+}
+
+// Renews the token (or logs in again) at half its lease, until done is closed.
+func (vc *vaultClient) renewLoop(done <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if vc.opts.Token != "" && !vc.discoverLease(ctx, done) {
+		return
+	}
+
+	for {
+		vc.mut.Lock()
+		renewAt := vc.renewAt
+		vc.mut.Unlock()
+
+		wait := time.Hour
+		if !renewAt.IsZero() {
+			wait = time.Until(renewAt)
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-done:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		if renewAt.IsZero() {
+			continue
+		}
+
+		err := vc.renew(ctx)
+		if err != nil {
+			logrus.Errorf("configstore: vault: token renewal: %s", err)
+			vc.mut.Lock()
+			// retry later
+			vc.renewAt = time.Now().Add(10 * time.Second)
+			vc.mut.Unlock()
+		}
+	}
+}
+
+// Looks up the lease of the static token, retrying with backoff until it succeeds.
+// Returns false if done got closed first.
+func (vc *vaultClient) discoverLease(ctx context.Context, done <-chan struct{}) bool {
+	delay := vc.retryDelay
+	for {
+		err := vc.lookupSelf(ctx)
+		if err == nil {
+			return true
+		}
+		logrus.Warnf("configstore: vault: token lookup, retrying in %s: %s", delay, err)
+		t := time.NewTimer(delay)
+		select {
+		case <-done:
+			t.Stop()
+			return false
+		case <-t.C:
+		}
+		delay *= 2
+		if delay > time.Minute {
+			delay = time.Minute
+		}
+	}
+}
+
+func (vc *vaultClient) lookupSelf(ctx context.Context) error {
+	resp := struct {
+		Data struct {
+			TTL       int64 `json:"ttl"`
+			Renewable bool  `json:"renewable"`
+		} `json:"data"`
+	}{}
+	err := vc.request(ctx, http.MethodGet, "/v1/auth/token/lookup-self", vc.opts.Token, nil, &resp)
+	if err != nil {
+		return err
+	}
+	a := vaultAuth{}
+	a.Auth.LeaseDuration = resp.Data.TTL
+	a.Auth.Renewable = resp.Data.Renewable
+	vc.setAuth(a)
+	return nil
+}
+
+func (vc *vaultClient) renew(ctx context.Context) error {
+	vc.mut.Lock()
+	token, renewable := vc.token, vc.renewable
+	vc.mut.Unlock()
+
+	if renewable {
+		resp := vaultAuth{}
+		err := vc.request(ctx, http.MethodPost, "/v1/auth/token/renew-self", token, map[string]string{}, &resp)
+		if err == nil {
+			vc.setAuth(resp)
+			return nil
+		}
+		if vc.opts.RoleID == "" {
+			return err
+		}
+	}
+	return vc.login(ctx)
+}
@@ -0,0 +1,148 @@
+package configstore
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeVault emulates the AppRole login, token lookup/renewal and KV v2 read endpoints of the Vault API.
+type fakeVault struct {
+	mut     sync.Mutex
+	logins  int
+	lookups int
+	renews  int
+	// number of lookup-self calls failing before one succeeds
+	lookupFailures int
+	secrets        map[string]map[string]interface{}
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	auth := func(token string) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": token, "lease_duration": 60, "renewable": true},
+		})
+	}
+
+	switch r.URL.Path {
+	case "/v1/auth/approle/login":
+		body := map[string]string{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["role_id"] != "role" || body["secret_id"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors": ["invalid role or secret ID"]}`))
+			return
+		}
+		f.logins++
+		auth("s.token")
+		return
+	case "/v1/auth/token/lookup-self":
+		f.lookups++
+		if f.lookups <= f.lookupFailures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"errors": ["sealed"]}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"ttl": 60, "renewable": true}}`))
+		return
+	case "/v1/auth/token/renew-self":
+		f.renews++
+		auth("")
+		return
+	}
+
+	if r.Header.Get("X-Vault-Token") != "s.token" {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors": ["permission denied"]}`))
+		return
+	}
+	s, ok := f.secrets[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors": []}`))
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": s}})
+}
+
+func TestVault(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := &fakeVault{secrets: map[string]map[string]interface{}{
+		"/v1/secret/data/myapp/db":    {"username": "admin", "password": "hunter2", "port": 5432},
+		"/v1/secret/data/myapp/cache": {"host": "redis", "port": 6379},
+	}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := VaultCustom([]string{"secret/myapp/db"}, VaultOptions{Address: srv.URL, RoleID: "role", SecretID: "secret"})
+	defer UnregisterProvider("vault:secret/myapp/db")
+
+	l, err := p.Items()
+	assert.NoError(err)
+	assert.Len(l.Items, 3)
+	items := (&ItemList{Items: l.Items}).index()
+	i, err := items.GetItem("password")
+	assert.NoError(err)
+	assert.True(i.Sensitive())
+	assert.Equal("hunter2", mustValue(i))
+	assert.Equal(int64(5432), must(items.GetItemValueInt("port")))
+	fake.mut.Lock()
+	assert.Equal(1, fake.logins)
+	fake.mut.Unlock()
+
+	p = VaultCustom([]string{"secret/myapp/cache"}, VaultOptions{Address: srv.URL, Token: "s.token", JSON: true})
+	defer UnregisterProvider("vault:secret/myapp/cache")
+	l, err = p.Items()
+	assert.NoError(err)
+	assert.Len(l.Items, 1)
+	assert.Equal("myapp/cache", l.Items[0].Key())
+	assert.JSONEq(`{"host": "redis", "port": 6379}`, mustValue(l.Items[0]))
+
+	p = VaultCustom([]string{"secret/unknown"}, VaultOptions{Address: srv.URL, Token: "bad"})
+	defer UnregisterProvider("vault:secret/unknown")
+	_, err = p.Items()
+	assert.EqualError(err, "secret/unknown: vault: 403 Forbidden: permission denied")
+}
+
+func TestVaultRenewal(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := &fakeVault{lookupFailures: 2}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	ctx := context.Background()
+
+	// the lease of a static token is looked up until it succeeds
+	vc := &vaultClient{opts: VaultOptions{Address: srv.URL, Token: "s.token", Client: srv.Client()}, token: "s.token", retryDelay: time.Millisecond}
+	assert.True(vc.discoverLease(ctx, make(chan struct{})))
+	assert.Equal(3, fake.lookups)
+	assert.True(vc.renewable)
+	assert.WithinDuration(time.Now().Add(30*time.Second), vc.renewAt, 5*time.Second)
+
+	assert.NoError(vc.renew(ctx))
+	assert.Equal(1, fake.renews)
+	assert.Equal(0, fake.logins)
+
+	// not renewable: logs in again via AppRole
+	vc = &vaultClient{opts: VaultOptions{Address: srv.URL, RoleID: "role", SecretID: "secret", Client: srv.Client()}}
+	assert.NoError(vc.renew(ctx))
+	assert.Equal(1, fake.logins)
+	assert.Equal("s.token", vc.token)
+
+	// stops retrying once the provider is closed
+	fake.lookups, fake.lookupFailures = 0, 1000
+	done := make(chan struct{})
+	close(done)
+	vc = &vaultClient{opts: VaultOptions{Address: srv.URL, Token: "s.token", Client: srv.Client()}, retryDelay: time.Hour}
+	assert.False(vc.discoverLease(ctx, done))
+}